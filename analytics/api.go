@@ -0,0 +1,358 @@
+package analytics
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"golang.org/x/mod/semver"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ChangeKind tells how an exported symbol changed between two versions.
+type ChangeKind string
+
+const (
+	Added   ChangeKind = "added"
+	Removed ChangeKind = "removed"
+	Changed ChangeKind = "changed"
+)
+
+// APIChange is a change to an exported symbol: a function, type, method, struct field,
+// interface method, constant or variable.
+type APIChange struct {
+	Package  string
+	Symbol   string     // ie. Repo, Repo.Parse or Config.IncludeTests
+	Kind     ChangeKind // added, removed or changed
+	Breaking bool       // callers or implementers may fail to compile
+	From, To string     // signatures, empty on the side where the symbol doesn't exist
+}
+
+// apiSymbol is an exported symbol with its signature. Signatures leave out parameter names
+// and constant values, so renaming parameters or changing values isn't seen as a change.
+type apiSymbol struct {
+	Signature   string
+	IfaceMethod bool // methods added to an interface break its implementations
+}
+
+// api returns the exported API surface of the package, keyed by symbol.
+func (p *Package) api() map[string]apiSymbol {
+	api := make(map[string]apiSymbol)
+	for _, f := range p.files {
+		if f.Type == TestGo || f.facts == nil {
+			continue
+		}
+		for sym, s := range f.facts.API {
+			api[sym] = s
+		}
+	}
+	return api
+}
+
+// extractAPI returns the exported symbols declared in a file.
+func extractAPI(file *ast.File) map[string]apiSymbol {
+	api := make(map[string]apiSymbol)
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			addFunc(api, d)
+		case *ast.GenDecl:
+			addGenDecl(api, d)
+		}
+	}
+	return api
+}
+
+func addFunc(api map[string]apiSymbol, d *ast.FuncDecl) {
+	if !d.Name.IsExported() {
+		return
+	}
+	if d.Recv == nil || len(d.Recv.List) == 0 {
+		api[d.Name.Name] = apiSymbol{Signature: "func" + typeParams(d.Type.TypeParams) + signature(d.Type)}
+		return
+	}
+	recv := receiverType(d.Recv.List[0].Type)
+	typeName := strings.TrimPrefix(recv, "*")
+	if !token.IsExported(typeName) {
+		return
+	}
+	api[typeName+"."+d.Name.Name] = apiSymbol{Signature: "func (" + recv + ") " + d.Name.Name + signature(d.Type)}
+}
+
+func addGenDecl(api map[string]apiSymbol, d *ast.GenDecl) {
+	for _, spec := range d.Specs {
+		switch s := spec.(type) {
+		case *ast.TypeSpec:
+			if !s.Name.IsExported() {
+				continue
+			}
+			name := s.Name.Name
+			tp := typeParams(s.TypeParams)
+			switch t := s.Type.(type) {
+			case *ast.StructType:
+				api[name] = apiSymbol{Signature: "type " + name + tp + " struct"}
+				for _, field := range t.Fields.List {
+					for _, fieldName := range fieldNames(field) {
+						if token.IsExported(fieldName) {
+							api[name+"."+fieldName] = apiSymbol{Signature: fieldName + " " + types.ExprString(field.Type)}
+						}
+					}
+				}
+			case *ast.InterfaceType:
+				api[name] = apiSymbol{Signature: "type " + name + tp + " interface"}
+				for _, m := range t.Methods.List {
+					ft, ok := m.Type.(*ast.FuncType)
+					if !ok {
+						// embedded interface or type constraint
+						api[name+"."+types.ExprString(m.Type)] = apiSymbol{Signature: "embeds " + types.ExprString(m.Type), IfaceMethod: true}
+						continue
+					}
+					for _, n := range m.Names {
+						if n.IsExported() {
+							api[name+"."+n.Name] = apiSymbol{Signature: n.Name + signature(ft), IfaceMethod: true}
+						}
+					}
+				}
+			default:
+				assign := " "
+				if s.Assign.IsValid() {
+					assign = " = "
+				}
+				api[name] = apiSymbol{Signature: "type " + name + tp + assign + types.ExprString(s.Type)}
+			}
+		case *ast.ValueSpec:
+			for _, n := range s.Names {
+				if !n.IsExported() {
+					continue
+				}
+				sig := d.Tok.String() + " " + n.Name
+				if s.Type != nil {
+					sig += " " + types.ExprString(s.Type)
+				}
+				api[n.Name] = apiSymbol{Signature: sig}
+			}
+		}
+	}
+}
+
+// fieldNames returns the names of a struct field, or the type name for embedded fields.
+func fieldNames(field *ast.Field) []string {
+	if len(field.Names) == 0 {
+		return []string{strings.TrimPrefix(receiverType(field.Type), "*")}
+	}
+	names := make([]string, 0, len(field.Names))
+	for _, n := range field.Names {
+		names = append(names, n.Name)
+	}
+	return names
+}
+
+// signature renders the parameter and result types of a function, without names.
+func signature(ft *ast.FuncType) string {
+	sig := "(" + fieldTypes(ft.Params) + ")"
+	if ft.Results == nil || len(ft.Results.List) == 0 {
+		return sig
+	}
+	results := fieldTypes(ft.Results)
+	if len(ft.Results.List) == 1 && len(ft.Results.List[0].Names) <= 1 {
+		return sig + " " + results
+	}
+	return sig + " (" + results + ")"
+}
+
+func typeParams(fl *ast.FieldList) string {
+	if fl == nil || len(fl.List) == 0 {
+		return ""
+	}
+	return "[" + fieldTypes(fl) + "]"
+}
+
+func fieldTypes(fl *ast.FieldList) string {
+	if fl == nil {
+		return ""
+	}
+	parts := make([]string, 0, len(fl.List))
+	for _, field := range fl.List {
+		n := max(len(field.Names), 1)
+		for range n {
+			parts = append(parts, types.ExprString(field.Type))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// importable tells if other modules can import the package, which rules out commands and
+// internal packages.
+func (p *Package) importable() bool {
+	if p.Name == "main" {
+		return false
+	}
+	dir := "/" + path.Dir(p.Location) + "/"
+	return !strings.Contains(dir, "/internal/")
+}
+
+// APIDiff compares the exported API of the importable packages of two module snapshots.
+func APIDiff(from, to *Module) []APIChange {
+	changes := make([]APIChange, 0)
+	names := make(map[string]struct{})
+	for _, pkg := range from.Packages {
+		names[pkg.Name] = struct{}{}
+	}
+	for _, pkg := range to.Packages {
+		names[pkg.Name] = struct{}{}
+	}
+	for name := range names {
+		before, after := packageAPI(from, name), packageAPI(to, name)
+		for sym, old := range before {
+			now, ok := after[sym]
+			switch {
+			case !ok:
+				changes = append(changes, APIChange{Package: name, Symbol: sym, Kind: Removed, Breaking: true, From: old.Signature})
+			case now.Signature != old.Signature:
+				changes = append(changes, APIChange{Package: name, Symbol: sym, Kind: Changed, Breaking: true, From: old.Signature, To: now.Signature})
+			}
+		}
+		for sym, now := range after {
+			if _, ok := before[sym]; ok {
+				continue
+			}
+			// a new interface method breaks existing implementations, unless the interface is new:
+			_, ifaceExisted := before[strings.SplitN(sym, ".", 2)[0]]
+			breaking := now.IfaceMethod && ifaceExisted
+			changes = append(changes, APIChange{Package: name, Symbol: sym, Kind: Added, Breaking: breaking, To: now.Signature})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Package != changes[j].Package {
+			return changes[i].Package < changes[j].Package
+		}
+		return changes[i].Symbol < changes[j].Symbol
+	})
+	return changes
+}
+
+// packageAPI returns the API of a package in the module, empty if the package doesn't exist
+// or can't be imported.
+func packageAPI(m *Module, name string) map[string]apiSymbol {
+	pkg, ok := m.GetPackage(name)
+	if !ok || !pkg.importable() {
+		return nil
+	}
+	return pkg.api()
+}
+
+// SemverReport checks a planned release of a local module against the API changes since the
+// latest tag.
+type SemverReport struct {
+	Module  *Module // the module at the latest tag
+	Head    *Module // the module at HEAD
+	Changes []APIChange
+	Planned string // the planned next version, may be empty
+}
+
+// Breaking returns the number of breaking changes.
+func (s SemverReport) Breaking() int {
+	n := 0
+	for _, c := range s.Changes {
+		if c.Breaking {
+			n++
+		}
+	}
+	return n
+}
+
+// Compatible returns the number of compatible changes.
+func (s SemverReport) Compatible() int {
+	return len(s.Changes) - s.Breaking()
+}
+
+// Suggested returns the smallest next version allowed by the changes. Breaking changes need a
+// major bump, or a minor bump for v0; additions need a minor bump; anything else is a patch.
+func (s SemverReport) Suggested() string {
+	major, minor, patch := splitVersion(s.Module.Ref)
+	switch {
+	case s.Breaking() > 0 && major > 0:
+		return fmt.Sprintf("v%d.0.0", major+1)
+	case s.Breaking() > 0 || s.Compatible() > 0:
+		return fmt.Sprintf("v%d.%d.0", major, minor+1)
+	default:
+		return fmt.Sprintf("v%d.%d.%d", major, minor, patch+1)
+	}
+}
+
+// Violation tells if the planned version isn't a major bump despite breaking changes. v0
+// modules make no compatibility promise and are exempt.
+func (s SemverReport) Violation() bool {
+	if s.Planned == "" || s.Breaking() == 0 || semver.Major(s.Module.Ref) == "v0" {
+		return false
+	}
+	return semver.Major(s.Planned) == semver.Major(s.Module.Ref)
+}
+
+// splitVersion returns the numeric parts of a semantic version, ignoring pre-release and build.
+func splitVersion(v string) (major, minor, patch int) {
+	core := strings.TrimPrefix(semver.Canonical(v), "v")
+	core, _, _ = strings.Cut(core, "-")
+	parts := strings.Split(core, ".")
+	nums := make([]int, 3)
+	for i := 0; i < len(parts) && i < 3; i++ {
+		nums[i], _ = strconv.Atoi(parts[i])
+	}
+	return nums[0], nums[1], nums[2]
+}
+
+// SemverCheck compares the exported API of a local module between its latest tag and HEAD. The
+// planned version is optional.
+func (r *Repo) SemverCheck(modPath, planned string) (*SemverReport, error) {
+	if planned != "" && !semver.IsValid(planned) {
+		return nil, fmt.Errorf("planned version %q is not a semantic version", planned)
+	}
+	current, ok := r.GetModule(modPath)
+	if !ok {
+		return nil, fmt.Errorf("no module %s", modPath)
+	}
+	if !semver.IsValid(current.LatestVersion) {
+		return nil, fmt.Errorf("module %s has no release tag", modPath)
+	}
+	tagged, err := r.ModuleAt(modPath, current.LatestVersion)
+	if err != nil {
+		return nil, fmt.Errorf("latest tag: %w", err)
+	}
+	head, err := r.ModuleAt(modPath, "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("HEAD: %w", err)
+	}
+	return &SemverReport{Module: tagged, Head: head, Changes: APIDiff(tagged, head), Planned: planned}, nil
+}
+
+// Library tells if the module has packages other modules can import.
+func (m *Module) Library() bool {
+	for _, pkg := range m.Packages {
+		if pkg.importable() {
+			return true
+		}
+	}
+	return false
+}
+
+// SemverReports checks every tagged local library module, see SemverCheck. Modules that fail
+// the check are logged and left out.
+func (r *Repo) SemverReports() []*SemverReport {
+	reports := make([]*SemverReport, 0)
+	for _, mod := range r.ModuleFilter(DepTypeLocal, "") {
+		if !mod.Library() || !semver.IsValid(mod.LatestVersion) {
+			continue
+		}
+		report, err := r.SemverCheck(mod.Path, "")
+		if err != nil {
+			slog.Warn("semver check", "module", mod.Path, "error", err)
+			continue
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
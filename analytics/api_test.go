@@ -0,0 +1,84 @@
+package analytics
+
+import "testing"
+
+func TestAPIDiff(t *testing.T) {
+	from := writeModule(t, "example.com/lib", map[string]string{
+		"lib.go": "package lib\n\n" +
+			"type Store interface {\n\tGet(key string) string\n}\n\n" +
+			"type Config struct {\n\tName string\n\tsecret string\n}\n\n" +
+			"func Open(name string) (*Config, error) { return nil, nil }\n\n" +
+			"func Close(c *Config) {}\n\n" +
+			"func (c *Config) Valid() bool { return true }\n",
+		"internal/impl/impl.go": "package impl\n\nfunc Helper() {}\n",
+	})
+	to := writeModule(t, "example.com/lib", map[string]string{
+		"lib.go": "package lib\n\n" +
+			"type Store interface {\n\tGet(key string) string\n\tPut(key, value string)\n}\n\n" +
+			"type Config struct {\n\tName string\n\tDebug bool\n}\n\n" +
+			"func Open(path string) (*Config, error) { return nil, nil }\n\n" +
+			"func (c *Config) Valid(strict bool) bool { return true }\n\n" +
+			"func New() *Config { return nil }\n",
+		"internal/impl/impl.go": "package impl\n\nfunc Other() {}\n",
+	})
+	for _, m := range []*Module{from, to} {
+		if err := m.LoadSource(); err != nil {
+			t.Fatalf("LoadSource: %v", err)
+		}
+	}
+	want := map[string]struct {
+		kind     ChangeKind
+		breaking bool
+	}{
+		"Close":        {Removed, true},
+		"Config.Debug": {Added, false},
+		"Config.Valid": {Changed, true},
+		"New":          {Added, false},
+		"Store.Put":    {Added, true},
+	}
+	changes := APIDiff(from, to)
+	if len(changes) != len(want) {
+		t.Errorf("APIDiff() = %d changes, want %d: %+v", len(changes), len(want), changes)
+	}
+	for _, c := range changes {
+		w, ok := want[c.Symbol]
+		if !ok {
+			t.Errorf("unexpected change %+v", c)
+			continue
+		}
+		if c.Kind != w.kind || c.Breaking != w.breaking {
+			t.Errorf("%s: %s breaking=%v, want %s breaking=%v", c.Symbol, c.Kind, c.Breaking, w.kind, w.breaking)
+		}
+	}
+}
+
+func TestSemverReport(t *testing.T) {
+	breaking := []APIChange{{Symbol: "Close", Kind: Removed, Breaking: true}}
+	compatible := []APIChange{{Symbol: "New", Kind: Added}}
+	tests := []struct {
+		name          string
+		tag           string
+		changes       []APIChange
+		planned       string
+		wantSuggested string
+		wantViolation bool
+	}{
+		{name: "breaking minor", tag: "v1.2.3", changes: breaking, planned: "v1.3.0", wantSuggested: "v2.0.0", wantViolation: true},
+		{name: "breaking major", tag: "v1.2.3", changes: breaking, planned: "v2.0.0", wantSuggested: "v2.0.0"},
+		{name: "breaking v0", tag: "v0.4.1", changes: breaking, planned: "v0.4.2", wantSuggested: "v0.5.0"},
+		{name: "compatible", tag: "v1.2.3", changes: compatible, planned: "v1.3.0", wantSuggested: "v1.3.0"},
+		{name: "no changes", tag: "v1.2.3", wantSuggested: "v1.2.4"},
+		{name: "nothing planned", tag: "v1.2.3", changes: breaking, wantSuggested: "v2.0.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := SemverReport{Module: &Module{Ref: tt.tag}, Changes: tt.changes, Planned: tt.planned}
+			if got := s.Suggested(); got != tt.wantSuggested {
+				t.Errorf("Suggested() = %s, want %s", got, tt.wantSuggested)
+			}
+			if got := s.Violation(); got != tt.wantViolation {
+				t.Errorf("Violation() = %v, want %v", got, tt.wantViolation)
+			}
+		})
+	}
+}
@@ -0,0 +1,415 @@
+package analytics
+
+import (
+	"go/ast"
+	"go/token"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Binary is an executable built from a directory with a main package in a local module.
+type Binary struct {
+	Name        string // what go build names it, the last element of the import path
+	Dir         string // directory relative to the module, "." for the module root
+	Module      *Module
+	Entrypoint  *Function  // func main, nil if it wasn't found
+	Files       []*File    // the production files of the main package
+	Deps        []*Package // local packages linked into the binary, directly or transitively
+	Flags       []Flag     // command line flags defined by the binary and its local dependencies
+	Dockerfiles []string   // paths of Dockerfiles in the module referring to the binary
+}
+
+// Flag is a command line flag defined with the flag package, pflag or cobra.
+type Flag struct {
+	Name      string
+	Shorthand string
+	Usage     string
+	File      *File
+	Line      int
+}
+
+// LibraryLinks is a local package and the binaries linking it.
+type LibraryLinks struct {
+	Package  *Package
+	Binaries []*Binary
+}
+
+type flagFacts struct {
+	Name      string
+	Shorthand string
+	Usage     string
+	Line      int
+}
+
+// ImportPath returns the import path of the main package.
+func (b *Binary) ImportPath() string {
+	return path.Join(b.Module.Path, b.Dir)
+}
+
+// Lines returns the lines of the main package and the local packages linked into the binary.
+func (b *Binary) Lines() int {
+	lines := 0
+	for _, f := range b.Files {
+		lines += f.Lines()
+	}
+	for _, p := range b.Deps {
+		for _, f := range p.files {
+			if f.Type != TestGo {
+				lines += f.Lines()
+			}
+		}
+	}
+	return lines
+}
+
+// Binaries returns the binaries built from the main packages of a local module, by directory.
+// Packages are known by name, so the main packages of a module are one Package, and binaries
+// are told apart by the directories of the files.
+func (m *Module) Binaries() []*Binary {
+	binaries := make([]*Binary, 0)
+	if m.Type != DepTypeLocal {
+		return binaries
+	}
+	main, ok := m.GetPackage("main")
+	if !ok {
+		return binaries
+	}
+	byDir := make(map[string]*Binary)
+	for _, f := range main.files {
+		if f.Type == TestGo {
+			continue
+		}
+		dir := path.Dir(f.Path)
+		b, ok := byDir[dir]
+		if !ok {
+			b = &Binary{Name: binaryName(path.Join(m.Path, dir)), Dir: dir, Module: m}
+			byDir[dir] = b
+			binaries = append(binaries, b)
+		}
+		b.Files = append(b.Files, f)
+		for _, fn := range f.Functions() {
+			if fn.Name == "main" && fn.Receiver == "" {
+				b.Entrypoint = fn
+			}
+		}
+	}
+	for _, b := range binaries {
+		b.Deps = linkedPackages(b.Files)
+		b.Flags = binaryFlags(b)
+		b.Dockerfiles = m.dockerfilesReferring(b)
+	}
+	sort.Slice(binaries, func(i, j int) bool {
+		return binaries[i].Dir < binaries[j].Dir
+	})
+	return binaries
+}
+
+// binaryIndex holds the binaries of the local modules and the binaries linking each package.
+// They are found once, on first use, as the analysis doesn't change after Parse.
+type binaryIndex struct {
+	once       sync.Once
+	binaries   []*Binary
+	linkedInto map[*Package][]*Binary
+}
+
+// index returns the binaries of the local modules, finding them on first use.
+func (r *Repo) index() *binaryIndex {
+	r.binaries.once.Do(func() {
+		binaries := make([]*Binary, 0)
+		for _, mod := range r.modules {
+			binaries = append(binaries, mod.Binaries()...)
+		}
+		sort.Slice(binaries, func(i, j int) bool {
+			return binaries[i].ImportPath() < binaries[j].ImportPath()
+		})
+		linkedInto := make(map[*Package][]*Binary)
+		for _, b := range binaries {
+			for _, p := range b.Deps {
+				linkedInto[p] = append(linkedInto[p], b)
+			}
+		}
+		r.binaries.binaries, r.binaries.linkedInto = binaries, linkedInto
+	})
+	return &r.binaries
+}
+
+// Binaries returns the binaries of the local modules, by import path.
+func (r *Repo) Binaries() []*Binary {
+	return slices.Clone(r.index().binaries)
+}
+
+// LinkedInto returns the binaries of the local modules linking the package.
+func (p *Package) LinkedInto() []*Binary {
+	linking := make([]*Binary, 0)
+	return append(linking, p.Module.Repo.index().linkedInto[p]...)
+}
+
+// LinkedLibraries returns the local packages linked into binaries, with the binaries linking
+// them, sorted by module and package.
+func (r *Repo) LinkedLibraries() []LibraryLinks {
+	linkedInto := r.index().linkedInto
+	libraries := make([]LibraryLinks, 0, len(linkedInto))
+	for p, binaries := range linkedInto {
+		libraries = append(libraries, LibraryLinks{Package: p, Binaries: slices.Clone(binaries)})
+	}
+	sort.Slice(libraries, func(i, j int) bool {
+		a, b := libraries[i].Package, libraries[j].Package
+		if a.Module.Path != b.Module.Path {
+			return a.Module.Path < b.Module.Path
+		}
+		return a.Name < b.Name
+	})
+	return libraries
+}
+
+// binaryName returns the name go build gives the binary of a main package.
+func binaryName(importPath string) string {
+	elems := strings.Split(importPath, "/")
+	if len(elems) > 1 && majorVersion.MatchString(elems[len(elems)-1]) {
+		return elems[len(elems)-2]
+	}
+	return elems[len(elems)-1]
+}
+
+// linkedPackages returns the packages of local modules the files import, directly or
+// transitively, sorted by module and package. File.Imports leaves out the imports within a
+// module, so the imports are resolved from the facts.
+func linkedPackages(files []*File) []*Package {
+	seen := make(map[*Package]bool)
+	var visit func(f *File)
+	visit = func(f *File) {
+		if f.facts == nil {
+			return
+		}
+		for _, imp := range f.facts.Imports {
+			p, ok := f.Module.Repo.packageOf(f.Module, imp)
+			if !ok || seen[p] || p.Name == "main" || p.Module.Type != DepTypeLocal {
+				continue
+			}
+			seen[p] = true
+			for _, pf := range p.files {
+				if pf.Type != TestGo {
+					visit(pf)
+				}
+			}
+		}
+	}
+	for _, f := range files {
+		visit(f)
+	}
+	deps := make([]*Package, 0, len(seen))
+	for p := range seen {
+		deps = append(deps, p)
+	}
+	sort.Slice(deps, func(i, j int) bool {
+		if deps[i].Module.Path != deps[j].Module.Path {
+			return deps[i].Module.Path < deps[j].Module.Path
+		}
+		return deps[i].Name < deps[j].Name
+	})
+	return deps
+}
+
+// binaryFlags returns the flags defined in the files of the binary and its dependencies.
+func binaryFlags(b *Binary) []Flag {
+	flags := make([]Flag, 0)
+	add := func(f *File) {
+		if f.facts == nil {
+			return
+		}
+		for _, ff := range f.facts.Flags {
+			flags = append(flags, Flag{Name: ff.Name, Shorthand: ff.Shorthand, Usage: ff.Usage, File: f, Line: ff.Line})
+		}
+	}
+	for _, f := range b.Files {
+		add(f)
+	}
+	for _, p := range b.Deps {
+		for _, f := range p.files {
+			if f.Type != TestGo {
+				add(f)
+			}
+		}
+	}
+	sort.SliceStable(flags, func(i, j int) bool {
+		return flags[i].Name < flags[j].Name
+	})
+	return flags
+}
+
+// isDockerfile tells if a file name is one of the usual names of Dockerfiles, leaving out
+// documentation like Dockerfile.md.
+func isDockerfile(name string) bool {
+	lower := strings.ToLower(name)
+	if ext := path.Ext(lower); ext == ".md" || ext == ".txt" {
+		return false
+	}
+	return lower == "dockerfile" || lower == "containerfile" ||
+		strings.HasPrefix(lower, "dockerfile.") || strings.HasSuffix(lower, ".dockerfile")
+}
+
+// dockerfilesReferring returns the Dockerfiles of the module referring to the binary, see
+// dockerfileRefers.
+func (m *Module) dockerfilesReferring(b *Binary) []string {
+	referring := make([]string, 0)
+	for name, content := range m.dockerfiles {
+		if dockerfileRefers(content, b) {
+			referring = append(referring, name)
+		}
+	}
+	sort.Strings(referring)
+	return referring
+}
+
+// dockerfileRefers tells if a Dockerfile builds the binary, with go build or go install of
+// its directory, like ./cmd/app, or with -o naming it; runs it, with the ENTRYPOINT or CMD
+// executable named like it; or copies it from another stage, with COPY --from. Other paths
+// named like the binary, like the /app in COPY . /app, don't count.
+func dockerfileRefers(content string, b *Binary) bool {
+	named := func(p string) bool {
+		return path.Base(p) == b.Name
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\\\n", " "), "\n") {
+		fields := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ' ' || r == '\t' || r == '"' || r == '\'' || r == ',' || r == '[' || r == ']'
+		})
+		if len(fields) < 2 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "RUN":
+			if goBuildRefers(fields[1:], b, named) {
+				return true
+			}
+		case "ENTRYPOINT", "CMD":
+			if named(fields[1]) {
+				return true
+			}
+		case "COPY", "ADD":
+			var from bool
+			var sources []string
+			for _, field := range fields[1:] {
+				if strings.HasPrefix(field, "--") {
+					from = from || strings.HasPrefix(field, "--from=")
+					continue
+				}
+				sources = append(sources, field)
+			}
+			// the last path is the destination
+			if from && len(sources) > 1 && slices.ContainsFunc(sources[:len(sources)-1], named) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// goBuildRefers tells if the go build and go install commands in the arguments of a RUN
+// instruction build the binary.
+func goBuildRefers(args []string, b *Binary, named func(string) bool) bool {
+	building := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "&&" || arg == "||" || arg == ";":
+			building = false
+		case i > 0 && args[i-1] == "go" && (arg == "build" || arg == "install"):
+			building = true
+		case !building:
+		case arg == "-o" && i+1 < len(args):
+			i++
+			if named(args[i]) {
+				return true
+			}
+		case strings.HasPrefix(arg, "-o="):
+			if named(strings.TrimPrefix(arg, "-o=")) {
+				return true
+			}
+		case strings.HasPrefix(arg, "-"):
+		default:
+			pkg := strings.TrimSuffix(strings.TrimPrefix(arg, "./"), "/...")
+			if b.Dir != "." && (pkg == b.Dir || strings.HasSuffix(pkg, "/"+b.Dir)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// flagDefinition matches the names of the functions and methods of flag and pflag defining a
+// flag, with a P suffix for those taking a shorthand.
+var flagDefinition = regexp.MustCompile(`^(?:(?:Bool|Duration|Float32|Float64|Int|Int8|Int16|Int32|Int64|Uint|Uint8|Uint16|Uint32|Uint64|String|IP|IPMask|IPNet|Count|BytesHex|BytesBase64)(?:Slice|Array|ToString|ToInt|ToInt64)?(?:Var)?|(Func|BoolFunc)|TextVar|Var)(P)?$`)
+
+// extractFlags finds the command line flags defined in a file, like flag.Int("port", 8080,
+// "port to listen on"), cmd.Flags().StringVarP(&name, "name", "n", "", "your name") or
+// fs.Bool on a flag set. Only flags with literal names are found.
+func extractFlags(file *ast.File, fset *token.FileSet) []flagFacts {
+	flagPackages := make(map[string]bool)
+	imported := make(map[string]bool)
+	for _, imp := range file.Imports {
+		p, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := path.Base(p)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		imported[name] = true
+		if p == "flag" || p == "github.com/spf13/pflag" {
+			flagPackages[name] = true
+		}
+	}
+	var flags []flagFacts
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) < 3 {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		m := flagDefinition.FindStringSubmatch(sel.Sel.Name)
+		if m == nil {
+			return true
+		}
+		// other packages have functions like these, zap.String for one
+		if x, ok := sel.X.(*ast.Ident); ok && x.Obj == nil && imported[x.Name] && !flagPackages[x.Name] {
+			return true
+		}
+		str := func(i int) (string, bool) {
+			if i < 0 || i >= len(call.Args) {
+				return "", false
+			}
+			return stringLit(call.Args[i])
+		}
+		// the name is the first argument, or the second after the variable
+		i := 0
+		name, ok := str(i)
+		if !ok {
+			i = 1
+			if name, ok = str(i); !ok {
+				return true
+			}
+		}
+		f := flagFacts{Name: name, Line: fset.Position(call.Pos()).Line}
+		if m[2] != "" {
+			f.Shorthand, _ = str(i + 1)
+		}
+		// the usage comes last, except for Func and BoolFunc, which take the function last
+		usage := len(call.Args) - 1
+		if m[1] != "" {
+			usage--
+		}
+		f.Usage, _ = str(usage)
+		flags = append(flags, f)
+		return true
+	})
+	return flags
+}
@@ -0,0 +1,81 @@
+package analytics
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestBinaries(t *testing.T) {
+	r := &Repo{modules: make(map[string]*Module), snapshots: make(map[string]*Module)}
+	add := func(path string, files map[string]string) *Module {
+		m := writeModule(t, path, files)
+		m.Repo = r
+		r.modules[path] = m
+		if err := m.LoadSource(); err != nil {
+			t.Fatalf("LoadSource: %v", err)
+		}
+		return m
+	}
+	add("example.com/lib/v2", map[string]string{
+		"lib.go": "package lib\n\nimport \"example.com/lib/v2/conf\"\n\nvar Conf = conf.Load()\n",
+		"conf/conf.go": "package conf\n\nimport \"flag\"\n\nvar verbose = flag.Bool(\"v\", false, \"verbose\")\n\n" +
+			"func Load() bool {\n\treturn *verbose\n}\n",
+		"unused/unused.go": "package unused\n",
+	})
+	svc := add("example.com/svc", map[string]string{
+		"main.go": "package main\n\nimport (\n\tf \"flag\"\n\n\t\"example.com/lib/v2\"\n\t\"go.uber.org/zap\"\n)\n\n" +
+			"func main() {\n\tport := f.Int(\"port\", 8080, \"port to listen on\")\n\t_ = zap.String(\"port\", \"x\", \"y\")\n\t_ = lib.Conf\n\t_ = port\n}\n",
+		"cmd/svcctl/main.go": "package main\n\nimport \"github.com/spf13/cobra\"\n\nvar name string\n\n" +
+			"func main() {\n\tcmd := &cobra.Command{Use: \"svcctl\"}\n\tcmd.Flags().StringVarP(&name, \"name\", \"n\", \"\", \"your name\")\n" +
+			"\tcmd.PersistentFlags().Func(\"level\", \"log level\", func(string) error { return nil })\n}\n",
+		"cmd/svcctl/main_test.go": "package main\n\nimport \"flag\"\n\nvar short = flag.Bool(\"short\", false, \"\")\n",
+		"Dockerfile":              "FROM golang AS build\nRUN go build -o /out/ \\\n    ./cmd/svcctl\nFROM scratch\nCOPY --from=build /out/svcctl /svcctl\n",
+		"deploy/Dockerfile.svc":   "FROM scratch\nCOPY svc /app/svc\nENTRYPOINT [\"/app/svc\"]\n",
+		"docs/Dockerfile.md":      "RUN go build ./cmd/svcctl\n",
+		// paths named like the binary that aren't it:
+		"deploy/Dockerfile.other": "FROM golang\nWORKDIR /svc\nCOPY . /svc\nRUN make svc && go build -o /bin/tool ./tools/svc-gen\nCMD [\"svc.sh\"]\n",
+	})
+	for _, mod := range r.modules {
+		mod.resolveImports()
+	}
+
+	binaries := r.Binaries()
+	if len(binaries) != 2 {
+		t.Fatalf("got %d binaries, want 2", len(binaries))
+	}
+	describe := func(b *Binary) string {
+		var flags, deps []string
+		for _, f := range b.Flags {
+			flags = append(flags, fmt.Sprintf("%s/%s (%s) %s:%d", f.Name, f.Shorthand, f.Usage, f.File.Path, f.Line))
+		}
+		for _, p := range b.Deps {
+			deps = append(deps, p.Name)
+		}
+		return fmt.Sprintf("%s %s %s:%d flags=[%s] deps=[%s] dockerfiles=%v", b.Name, b.ImportPath(), b.Entrypoint.File.Path,
+			b.Entrypoint.StartLine, strings.Join(flags, ", "), strings.Join(deps, ", "), b.Dockerfiles)
+	}
+	want := []string{
+		"svc example.com/svc main.go:10 flags=[port/ (port to listen on) main.go:11, v/ (verbose) conf/conf.go:5] deps=[conf, lib] dockerfiles=[deploy/Dockerfile.svc]",
+		"svcctl example.com/svc/cmd/svcctl cmd/svcctl/main.go:7 flags=[level/ (log level) cmd/svcctl/main.go:10, name/n (your name) cmd/svcctl/main.go:9] deps=[] dockerfiles=[Dockerfile]",
+	}
+	for i, b := range binaries {
+		if got := describe(b); got != want[i] {
+			t.Errorf("binary %d:\n got %s\nwant %s", i, got, want[i])
+		}
+	}
+	if lines := binaries[0].Lines(); lines != 16+5+8 {
+		t.Errorf("lines of svc: %d", lines)
+	}
+	conf, _ := r.modules["example.com/lib/v2"].GetPackage("conf")
+	if linking := conf.LinkedInto(); len(linking) != 1 || linking[0] != binaries[0] {
+		t.Errorf("conf linked into %v", linking)
+	}
+	libraries := r.LinkedLibraries()
+	if len(libraries) != 2 || libraries[0].Package != conf || libraries[1].Binaries[0].Name != "svc" {
+		t.Errorf("libraries: %+v", libraries)
+	}
+	if _, ok := svc.GetPackage("main"); !ok || len(r.modules["example.com/lib/v2"].Binaries()) != 0 {
+		t.Error("a library has binaries")
+	}
+}
@@ -0,0 +1,232 @@
+package analytics
+
+import (
+	"fmt"
+	"golang.org/x/tools/cover"
+	"io/fs"
+	"log/slog"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Coverage is the statement coverage of a file, function, package or module.
+type Coverage struct {
+	Statements int // statements known from the coverage profiles
+	Covered    int // statements executed at least once
+}
+
+// HasData tells if any coverage profile covered the code.
+func (c Coverage) HasData() bool {
+	return c.Statements > 0
+}
+
+// Percent returns the share of covered statements, 0-100.
+func (c Coverage) Percent() float32 {
+	if c.Statements == 0 {
+		return 0
+	}
+	return float32(c.Covered) * 100 / float32(c.Statements)
+}
+
+func (c *Coverage) add(o Coverage) {
+	c.Statements += o.Statements
+	c.Covered += o.Covered
+}
+
+// LineStatus tells how a single source line is covered.
+type LineStatus int
+
+const (
+	NotInstrumented LineStatus = iota
+	LineCovered
+	LineUncovered
+)
+
+// LoadCoverage reads all coverage profiles (go test -coverprofile) found in dir and attaches the
+// statement coverage to the files of the local modules. Files in the profiles are mapped to
+// modules by import path.
+func (r *Repo) LoadCoverage(dir string) error {
+	profiles, unmapped := 0, 0
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		profs, err := cover.ParseProfiles(p)
+		if err != nil {
+			slog.Warn("skipping file, not a coverage profile", "file", p, "error", err)
+			return nil
+		}
+		profiles++
+		for _, prof := range profs {
+			f, ok := r.findFileByImportPath(prof.FileName)
+			if !ok {
+				unmapped++
+				continue
+			}
+			f.addCoverage(prof.Blocks)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("filepath.WalkDir: %w", err)
+	}
+	slog.Info("loaded coverage profiles", "profiles", profiles, "unmapped files", unmapped)
+	return nil
+}
+
+// findFileByImportPath locates a file in a local module from its import path,
+// ie. github.com/perbu/gogrok/analytics/file.go
+func (r *Repo) findFileByImportPath(name string) (*File, bool) {
+	mName, ok := r.FindModule(path.Dir(name))
+	if !ok {
+		return nil, false
+	}
+	mod, ok := r.GetModule(mName)
+	if !ok || mod.Type != DepTypeLocal {
+		return nil, false
+	}
+	return mod.FindFile(strings.TrimPrefix(name, mName+"/"))
+}
+
+// FindFile finds a file from its path relative to the module root.
+func (m *Module) FindFile(rel string) (*File, bool) {
+	for _, pkg := range m.Packages {
+		for _, f := range pkg.files {
+			if f.Path == rel {
+				return f, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// addCoverage merges profile blocks into the coverage of the file. The same block can show up
+// in several profiles, the counts are then added up.
+func (f *File) addCoverage(blocks []cover.ProfileBlock) {
+	for _, b := range blocks {
+		merged := false
+		for i, existing := range f.coverage {
+			if existing.StartLine == b.StartLine && existing.StartCol == b.StartCol &&
+				existing.EndLine == b.EndLine && existing.EndCol == b.EndCol {
+				f.coverage[i].Count += b.Count
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			f.coverage = append(f.coverage, b)
+		}
+	}
+	sort.Slice(f.coverage, func(i, j int) bool {
+		return f.coverage[i].StartLine < f.coverage[j].StartLine
+	})
+}
+
+// coverageBetween sums up the blocks starting within the given lines.
+func (f *File) coverageBetween(start, end int) Coverage {
+	var c Coverage
+	for _, b := range f.coverage {
+		if b.StartLine < start || b.StartLine > end {
+			continue
+		}
+		c.Statements += b.NumStmt
+		if b.Count > 0 {
+			c.Covered += b.NumStmt
+		}
+	}
+	return c
+}
+
+// Coverage returns the statement coverage of the file.
+func (f *File) Coverage() Coverage {
+	return f.coverageBetween(0, f.Lines())
+}
+
+// LineCoverage returns the coverage status for a line, numbered from 1. A line touched by
+// any block that never ran is uncovered.
+func (f *File) LineCoverage(line int) LineStatus {
+	status := NotInstrumented
+	for _, b := range f.coverage {
+		if line < b.StartLine || line > b.EndLine {
+			continue
+		}
+		if b.Count == 0 {
+			return LineUncovered
+		}
+		status = LineCovered
+	}
+	return status
+}
+
+// Coverage returns the statement coverage of the function.
+func (fn *Function) Coverage() Coverage {
+	return fn.File.coverageBetween(fn.StartLine, fn.EndLine)
+}
+
+// Coverage returns the statement coverage of the production files of the package.
+func (p *Package) Coverage() Coverage {
+	var c Coverage
+	for _, file := range p.countedFiles() {
+		c.add(file.Coverage())
+	}
+	return c
+}
+
+// Coverage returns the statement coverage of the module.
+func (m *Module) Coverage() Coverage {
+	var c Coverage
+	for _, pkg := range m.Packages {
+		c.add(pkg.Coverage())
+	}
+	return c
+}
+
+// RiskyFunction is a function ranked by how complex and how poorly covered it is.
+type RiskyFunction struct {
+	Function   *Function
+	Complexity float32
+	Coverage   Coverage
+	Risk       float32 // complexity weighted by the uncovered share of the function
+}
+
+// RiskiestFunctions returns the functions in local modules with coverage data, ordered by
+// complexity times the uncovered share. At most limit functions are returned.
+func (r *Repo) RiskiestFunctions(limit int) []RiskyFunction {
+	risky := make([]RiskyFunction, 0)
+	for _, mod := range r.modules {
+		if mod.Type != DepTypeLocal {
+			continue
+		}
+		for _, pkg := range mod.Packages {
+			for _, fn := range pkg.Functions() {
+				cov := fn.Coverage()
+				if !cov.HasData() {
+					continue
+				}
+				complexity := fn.CalculateComplexity()
+				risk := complexity * (1 - cov.Percent()/100)
+				if risk == 0 {
+					continue
+				}
+				risky = append(risky, RiskyFunction{
+					Function:   fn,
+					Complexity: complexity,
+					Coverage:   cov,
+					Risk:       risk,
+				})
+			}
+		}
+	}
+	sort.Slice(risky, func(i, j int) bool {
+		return risky[i].Risk > risky[j].Risk
+	})
+	if len(risky) > limit {
+		risky = risky[:limit]
+	}
+	return risky
+}
@@ -0,0 +1,75 @@
+package analytics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadCoverage(t *testing.T) {
+	m := writeModule(t, "example.com/calc", map[string]string{
+		"calc/calc.go": `package calc
+
+func Abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+func Neg(a int) int {
+	return -a
+}
+`,
+	})
+	if err := m.LoadSource(); err != nil {
+		t.Fatalf("LoadSource: %v", err)
+	}
+	profile := `mode: set
+example.com/calc/calc/calc.go:3.21,4.11 1 1
+example.com/calc/calc/calc.go:4.11,6.3 1 0
+example.com/calc/calc/calc.go:7.2,7.10 1 1
+example.com/calc/calc/calc.go:10.21,12.2 1 0
+example.com/other/other.go:1.1,2.2 1 1
+`
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "coverage.out"), []byte(profile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("not a profile"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Repo.LoadCoverage(dir); err != nil {
+		t.Fatalf("LoadCoverage: %v", err)
+	}
+	f, ok := m.FindFile("calc/calc.go")
+	if !ok {
+		t.Fatal("file not found")
+	}
+	if got := f.Coverage(); got != (Coverage{Statements: 4, Covered: 2}) {
+		t.Errorf("File.Coverage() = %+v, want 4 statements, 2 covered", got)
+	}
+	fns := f.Functions()
+	if len(fns) != 2 {
+		t.Fatalf("Functions() = %d, want 2", len(fns))
+	}
+	if got := fns[0].Coverage(); got != (Coverage{Statements: 3, Covered: 2}) {
+		t.Errorf("Abs coverage = %+v, want 3 statements, 2 covered", got)
+	}
+	if got := fns[1].Coverage().Percent(); got != 0 {
+		t.Errorf("Neg coverage = %v%%, want 0%%", got)
+	}
+	if got := f.LineCoverage(5); got != LineUncovered {
+		t.Errorf("LineCoverage(5) = %v, want uncovered", got)
+	}
+	if got := f.LineCoverage(7); got != LineCovered {
+		t.Errorf("LineCoverage(7) = %v, want covered", got)
+	}
+	if got := f.LineCoverage(9); got != NotInstrumented {
+		t.Errorf("LineCoverage(9) = %v, want not instrumented", got)
+	}
+	risky := m.Repo.RiskiestFunctions(10)
+	if len(risky) != 2 || risky[0].Function.Name != "Neg" {
+		t.Errorf("RiskiestFunctions() = %+v, want Neg first", risky)
+	}
+}
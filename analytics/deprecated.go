@@ -0,0 +1,324 @@
+package analytics
+
+import (
+	"go/ast"
+	"go/token"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// deprecatedSymbol is an exported declaration with a Deprecated: paragraph in its doc comment.
+type deprecatedSymbol struct {
+	Name    string // named like the API symbols: Func, Type, Type.Method, Type.Field
+	Message string // the paragraph, without the Deprecated: prefix
+	Line    int
+}
+
+// symbolRef is a use of an exported identifier through a package name: pkg.Name, pkg.Type.Member
+// or a field in a pkg.Type{Field: ...} literal.
+type symbolRef struct {
+	Qualifier string
+	Name      string // Name or Type.Member
+	Line      int
+}
+
+// importSpec is an import of a file, with the name it is imported as if it is renamed.
+type importSpec struct {
+	Path string
+	Name string
+	Line int
+}
+
+// DeprecatedUse is a reference from local code to a deprecated symbol or package.
+type DeprecatedUse struct {
+	File    *File
+	Line    int
+	Package *Package // the package declaring the symbol
+	Symbol  string   // Name or Type.Member, empty if the whole package is deprecated
+	Message string
+}
+
+// DeprecationReport is the uses of deprecated API in a local module.
+type DeprecationReport struct {
+	Module *Module
+	Uses   []DeprecatedUse
+}
+
+// DeprecationReports returns the local modules using deprecated API of other packages, local
+// or external with loaded source, sorted by module path. Methods called on values aren't found,
+// as that takes type checking.
+func (r *Repo) DeprecationReports() []DeprecationReport {
+	reports := make([]DeprecationReport, 0)
+	deprecations := make(map[*Package]map[string]deprecatedSymbol)
+	for _, mod := range r.modules {
+		if mod.Type != DepTypeLocal {
+			continue
+		}
+		if uses := mod.deprecatedUses(deprecations); len(uses) > 0 {
+			reports = append(reports, DeprecationReport{Module: mod, Uses: uses})
+		}
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Module.Path < reports[j].Module.Path
+	})
+	return reports
+}
+
+// DeprecatedUses returns the uses of deprecated API in the module, by file and line.
+func (m *Module) DeprecatedUses() []DeprecatedUse {
+	return m.deprecatedUses(make(map[*Package]map[string]deprecatedSymbol))
+}
+
+// deprecatedUses finds the deprecated uses, memoizing the deprecations of the packages looked at.
+func (m *Module) deprecatedUses(deprecations map[*Package]map[string]deprecatedSymbol) []DeprecatedUse {
+	uses := make([]DeprecatedUse, 0)
+	for _, pkg := range m.Packages {
+		for _, f := range pkg.files {
+			if f.facts == nil {
+				continue
+			}
+			byName := make(map[string]*Package, len(f.facts.ImportSpecs))
+			for _, spec := range f.facts.ImportSpecs {
+				p, ok := m.Repo.packageOf(m, spec.Path)
+				if !ok || p == pkg {
+					continue
+				}
+				if _, ok := deprecations[p]; !ok {
+					deprecations[p] = p.deprecations()
+				}
+				if d, ok := deprecations[p][""]; ok {
+					uses = append(uses, DeprecatedUse{File: f, Line: spec.Line, Package: p, Message: d.Message})
+				}
+				name := spec.Name
+				if name == "" {
+					name = p.Name
+				}
+				byName[name] = p
+			}
+			for _, ref := range f.facts.Refs {
+				p, ok := byName[ref.Qualifier]
+				if !ok {
+					continue
+				}
+				if d, ok := deprecations[p][ref.Name]; ok {
+					uses = append(uses, DeprecatedUse{File: f, Line: ref.Line, Package: p, Symbol: ref.Name, Message: d.Message})
+				}
+			}
+		}
+	}
+	sort.Slice(uses, func(i, j int) bool {
+		if uses[i].File.Path != uses[j].File.Path {
+			return uses[i].File.Path < uses[j].File.Path
+		}
+		if uses[i].Line != uses[j].Line {
+			return uses[i].Line < uses[j].Line
+		}
+		return uses[i].Symbol < uses[j].Symbol
+	})
+	return uses
+}
+
+// deprecations returns the deprecated symbols of the package by name, the package itself under
+// the empty name if its doc comment deprecates it.
+func (p *Package) deprecations() map[string]deprecatedSymbol {
+	deprecated := make(map[string]deprecatedSymbol)
+	for _, f := range p.files {
+		if f.Type == TestGo || f.facts == nil {
+			continue
+		}
+		for _, d := range f.facts.Deprecated {
+			deprecated[d.Name] = d
+		}
+		if f.facts.PackageDeprecated != "" {
+			deprecated[""] = deprecatedSymbol{Message: f.facts.PackageDeprecated}
+		}
+	}
+	return deprecated
+}
+
+// packageOf resolves an import path of a module to a package, in the module itself, another
+// local module or an external module with loaded source.
+func (r *Repo) packageOf(from *Module, imp string) (*Package, bool) {
+	if imp == from.Path || strings.HasPrefix(imp, from.Path+"/") {
+		return from.importedPackage(imp)
+	}
+	modPath, ok := r.FindModule(imp)
+	if !ok {
+		return nil, false
+	}
+	mod := r.modules[modPath]
+	if mod.Type != DepTypeLocal && !mod.SourceLoaded() {
+		return nil, false
+	}
+	return mod.importedPackage(imp)
+}
+
+// deprecationNotice returns the Deprecated: paragraph of a doc comment, on a single line.
+func deprecationNotice(doc *ast.CommentGroup) (string, bool) {
+	if doc == nil {
+		return "", false
+	}
+	for _, para := range strings.Split(doc.Text(), "\n\n") {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(para), "Deprecated:"); ok {
+			return strings.Join(strings.Fields(rest), " "), true
+		}
+	}
+	return "", false
+}
+
+// extractDeprecated finds the exported declarations of a file marked as deprecated. A doc
+// comment on a declaration group applies to the specs without their own.
+func extractDeprecated(file *ast.File, fset *token.FileSet) []deprecatedSymbol {
+	var deprecated []deprecatedSymbol
+	add := func(name string, ident *ast.Ident, docs ...*ast.CommentGroup) {
+		for _, doc := range docs {
+			if msg, ok := deprecationNotice(doc); ok {
+				deprecated = append(deprecated, deprecatedSymbol{Name: name, Message: msg, Line: fset.Position(ident.Pos()).Line})
+				return
+			}
+			if doc != nil {
+				return
+			}
+		}
+	}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() {
+				continue
+			}
+			if d.Recv == nil || len(d.Recv.List) == 0 {
+				add(d.Name.Name, d.Name, d.Doc)
+				continue
+			}
+			if typeName := strings.TrimPrefix(receiverType(d.Recv.List[0].Type), "*"); token.IsExported(typeName) {
+				add(typeName+"."+d.Name.Name, d.Name, d.Doc)
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if !s.Name.IsExported() {
+						continue
+					}
+					add(s.Name.Name, s.Name, s.Doc, d.Doc)
+					var members []*ast.Field
+					switch t := s.Type.(type) {
+					case *ast.StructType:
+						members = t.Fields.List
+					case *ast.InterfaceType:
+						members = t.Methods.List
+					}
+					for _, m := range members {
+						for _, n := range m.Names {
+							if n.IsExported() {
+								add(s.Name.Name+"."+n.Name, n, m.Doc)
+							}
+						}
+					}
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						if n.IsExported() {
+							add(n.Name, n, s.Doc, d.Doc)
+						}
+					}
+				}
+			}
+		}
+	}
+	return deprecated
+}
+
+// extractImports returns the imports of a file with the names they are imported as.
+func extractImports(file *ast.File, fset *token.FileSet) []importSpec {
+	specs := make([]importSpec, 0, len(file.Imports))
+	for _, imp := range file.Imports {
+		p, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		spec := importSpec{Path: p, Line: fset.Position(imp.Pos()).Line}
+		if imp.Name != nil {
+			spec.Name = imp.Name.Name
+		}
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+// extractRefs finds the uses of exported identifiers through the names the imports of the file
+// probably have. Package names aren't known until the imported packages are loaded, so every
+// likely name is kept, see packageNames.
+func extractRefs(file *ast.File, fset *token.FileSet, imports []importSpec) []symbolRef {
+	names := make(map[string]bool)
+	for _, spec := range imports {
+		if spec.Name != "" {
+			names[spec.Name] = true
+			continue
+		}
+		for _, n := range packageNames(spec.Path) {
+			names[n] = true
+		}
+	}
+	var refs []symbolRef
+	qualified := func(expr ast.Expr) (qualifier, name string, ok bool) {
+		sel, ok := expr.(*ast.SelectorExpr)
+		if !ok || !sel.Sel.IsExported() {
+			return "", "", false
+		}
+		x, ok := sel.X.(*ast.Ident)
+		if !ok || !names[x.Name] {
+			return "", "", false
+		}
+		return x.Name, sel.Sel.Name, true
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch e := n.(type) {
+		case *ast.SelectorExpr:
+			if q, name, ok := qualified(e); ok {
+				refs = append(refs, symbolRef{Qualifier: q, Name: name, Line: fset.Position(e.Sel.Pos()).Line})
+			} else if q, name, ok := qualified(e.X); ok && e.Sel.IsExported() {
+				refs = append(refs, symbolRef{Qualifier: q, Name: name + "." + e.Sel.Name, Line: fset.Position(e.Sel.Pos()).Line})
+			}
+		case *ast.CompositeLit:
+			q, name, ok := qualified(e.Type)
+			if !ok {
+				return true
+			}
+			for _, elt := range e.Elts {
+				if kv, ok := elt.(*ast.KeyValueExpr); ok {
+					if key, ok := kv.Key.(*ast.Ident); ok && key.IsExported() {
+						refs = append(refs, symbolRef{Qualifier: q, Name: name + "." + key.Name, Line: fset.Position(key.Pos()).Line})
+					}
+				}
+			}
+		}
+		return true
+	})
+	return refs
+}
+
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// packageNames returns the names a package with the import path is likely to have: the last
+// element of the path, skipping a major version, also without a go- prefix, a -go, .go or .vN
+// suffix, without dashes, or only the part after the last dash.
+func packageNames(imp string) []string {
+	elems := strings.Split(imp, "/")
+	last := elems[len(elems)-1]
+	if majorVersion.MatchString(last) && len(elems) > 1 {
+		last = elems[len(elems)-2]
+	}
+	base := strings.TrimSuffix(last, ".go")
+	if i := strings.LastIndex(base, ".v"); i > 0 {
+		base = base[:i]
+	}
+	base = strings.TrimSuffix(strings.TrimPrefix(base, "go-"), "-go")
+	names := []string{last, base, strings.ReplaceAll(base, "-", "")}
+	if i := strings.LastIndex(base, "-"); i >= 0 {
+		names = append(names, base[i+1:])
+	}
+	return names
+}
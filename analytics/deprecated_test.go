@@ -0,0 +1,74 @@
+package analytics
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDeprecatedUses(t *testing.T) {
+	r := &Repo{modules: make(map[string]*Module), snapshots: make(map[string]*Module)}
+	add := func(path string, files map[string]string) *Module {
+		m := writeModule(t, path, files)
+		m.Repo = r
+		r.modules[path] = m
+		if err := m.LoadSource(); err != nil {
+			t.Fatalf("LoadSource: %v", err)
+		}
+		return m
+	}
+	add("example.com/lib", map[string]string{
+		"lib.go": "package lib\n\n// Old does things.\n//\n// Deprecated: use New.\nfunc Old() {}\n\nfunc New() {}\n\n" +
+			"// Deprecated: Config is replaced by\n// Options.\ntype Config struct {\n\t// Deprecated: ignored.\n\tName string\n\tSize int\n}\n\n" +
+			"// Deprecated: unused.\nfunc (*Config) Reset() {}\n\n// Deprecated: use New.\nvar (\n\tA = 1\n\t// B is fine.\n\tB = 2\n)\n",
+		"lib_test.go":      "package lib\n\n// Deprecated: test files are skipped.\nfunc New() {}\n",
+		"legacy/legacy.go": "// Deprecated: use example.com/lib.\npackage legacy\n\nfunc Do() {}\n",
+	})
+	app := add("example.com/app", map[string]string{
+		"main.go": "package main\n\nimport (\n\t\"example.com/app/internal/old\"\n\t\"example.com/lib\"\n\tl2 \"example.com/lib/legacy\"\n)\n\n" +
+			"func main() {\n\tlib.Old()\n\tlib.New()\n\tc := lib.Config{Name: \"x\", Size: 1}\n\tc.Reset()\n\t_ = lib.A + lib.B\n\tl2.Do()\n\told.Thing()\n}\n",
+		"internal/old/old.go": "package old\n\n// Deprecated: gone.\nfunc Thing() {}\n",
+	})
+	for _, mod := range r.modules {
+		mod.resolveImports()
+	}
+
+	var got []string
+	for _, u := range app.DeprecatedUses() {
+		got = append(got, fmt.Sprintf("%s:%d %s.%s: %s", u.File.Path, u.Line, u.Package.Name, u.Symbol, u.Message))
+	}
+	want := []string{
+		"main.go:6 legacy.: use example.com/lib.",
+		"main.go:10 lib.Old: use New.",
+		"main.go:12 lib.Config: Config is replaced by Options.",
+		"main.go:12 lib.Config.Name: ignored.",
+		"main.go:14 lib.A: use New.",
+		"main.go:16 old.Thing: gone.",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("use %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+	reports := r.DeprecationReports()
+	if len(reports) != 1 || reports[0].Module != app || len(reports[0].Uses) != len(want) {
+		t.Errorf("reports: %+v", reports)
+	}
+}
+
+func TestPackageNames(t *testing.T) {
+	tests := map[string][]string{
+		"gopkg.in/yaml.v3":            {"yaml.v3", "yaml", "yaml"},
+		"github.com/go-chi/chi/v5":    {"chi", "chi", "chi"},
+		"github.com/mattn/go-sqlite3": {"go-sqlite3", "sqlite3", "sqlite3"},
+		"github.com/foo/bar-baz-go":   {"bar-baz-go", "bar-baz", "barbaz", "baz"},
+	}
+	for imp, want := range tests {
+		got := packageNames(imp)
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("packageNames(%q) = %q, want %q", imp, got, want)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package analytics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ModuleDiff is the difference between two snapshots of a local module.
+type ModuleDiff struct {
+	From, To        *Module
+	AddedPackages   []string
+	RemovedPackages []string
+	Dependencies    []DependencyChange // added, removed and changed requirements
+	Packages        []PackageDelta     // every package in either snapshot
+	NewImports      []CrossImport      // imports of other modules that weren't there before
+}
+
+// DependencyChange is a requirement that changed between two snapshots. From is empty for
+// added dependencies, To is empty for removed ones.
+type DependencyChange struct {
+	Path     string
+	From, To string
+}
+
+// PackageDelta compares the metrics of a package between two snapshots. The metrics are zero
+// on the side where the package doesn't exist.
+type PackageDelta struct {
+	Name                         string
+	FromLoC, ToLoC               int
+	FromComplexity, ToComplexity float32
+}
+
+// CrossImport is an import of another module's package.
+type CrossImport struct {
+	Package string // importing package
+	Import  string // imported package path
+}
+
+// LoCDelta returns the change in lines of code.
+func (d PackageDelta) LoCDelta() int {
+	return d.ToLoC - d.FromLoC
+}
+
+// ComplexityDelta returns the change in complexity.
+func (d PackageDelta) ComplexityDelta() float32 {
+	return d.ToComplexity - d.FromComplexity
+}
+
+// DiffModule compares a local module between two git refs. An empty ref is the working tree.
+func (r *Repo) DiffModule(path, from, to string) (*ModuleDiff, error) {
+	fromMod, err := r.ModuleAt(path, from)
+	if err != nil {
+		return nil, fmt.Errorf("from: %w", err)
+	}
+	toMod, err := r.ModuleAt(path, to)
+	if err != nil {
+		return nil, fmt.Errorf("to: %w", err)
+	}
+	return Diff(fromMod, toMod), nil
+}
+
+// Diff compares two snapshots of a module.
+func Diff(from, to *Module) *ModuleDiff {
+	d := &ModuleDiff{
+		From:            from,
+		To:              to,
+		AddedPackages:   make([]string, 0),
+		RemovedPackages: make([]string, 0),
+		Dependencies:    make([]DependencyChange, 0),
+		Packages:        make([]PackageDelta, 0),
+		NewImports:      make([]CrossImport, 0),
+	}
+	// packages:
+	names := make(map[string]struct{})
+	for _, pkg := range from.Packages {
+		names[pkg.Name] = struct{}{}
+	}
+	for _, pkg := range to.Packages {
+		names[pkg.Name] = struct{}{}
+	}
+	for name := range names {
+		delta := PackageDelta{Name: name}
+		fromPkg, inFrom := from.GetPackage(name)
+		if inFrom {
+			delta.FromLoC, delta.FromComplexity = fromPkg.Lines(), fromPkg.CalculateComplexity()
+		}
+		toPkg, inTo := to.GetPackage(name)
+		if inTo {
+			delta.ToLoC, delta.ToComplexity = toPkg.Lines(), toPkg.CalculateComplexity()
+		}
+		switch {
+		case !inFrom:
+			d.AddedPackages = append(d.AddedPackages, name)
+		case !inTo:
+			d.RemovedPackages = append(d.RemovedPackages, name)
+		}
+		d.Packages = append(d.Packages, delta)
+	}
+	sort.Strings(d.AddedPackages)
+	sort.Strings(d.RemovedPackages)
+	sort.Slice(d.Packages, func(i, j int) bool {
+		return d.Packages[i].Name < d.Packages[j].Name
+	})
+	// dependencies:
+	for dep, toVersion := range to.requires {
+		if fromVersion := from.requires[dep]; fromVersion != toVersion {
+			d.Dependencies = append(d.Dependencies, DependencyChange{Path: dep, From: fromVersion, To: toVersion})
+		}
+	}
+	for dep, fromVersion := range from.requires {
+		if _, ok := to.requires[dep]; !ok {
+			d.Dependencies = append(d.Dependencies, DependencyChange{Path: dep, From: fromVersion})
+		}
+	}
+	sort.Slice(d.Dependencies, func(i, j int) bool {
+		return d.Dependencies[i].Path < d.Dependencies[j].Path
+	})
+	// cross-module imports:
+	before := from.crossImports()
+	for ci := range to.crossImports() {
+		if _, ok := before[ci]; !ok {
+			d.NewImports = append(d.NewImports, ci)
+		}
+	}
+	sort.Slice(d.NewImports, func(i, j int) bool {
+		if d.NewImports[i].Package != d.NewImports[j].Package {
+			return d.NewImports[i].Package < d.NewImports[j].Package
+		}
+		return d.NewImports[i].Import < d.NewImports[j].Import
+	})
+	return d
+}
+
+// Empty tells if nothing changed between the snapshots.
+func (d *ModuleDiff) Empty() bool {
+	if len(d.AddedPackages)+len(d.RemovedPackages)+len(d.Dependencies)+len(d.NewImports) > 0 {
+		return false
+	}
+	for _, p := range d.Packages {
+		if p.LoCDelta() != 0 || p.ComplexityDelta() != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// crossImports returns the imports of packages belonging to the modules required in go.mod.
+func (m *Module) crossImports() map[CrossImport]struct{} {
+	imports := make(map[CrossImport]struct{})
+	for _, pkg := range m.Packages {
+		for _, f := range pkg.countedFiles() {
+			for _, imp := range f.facts.Imports {
+				if m.requiresImport(imp) {
+					imports[CrossImport{Package: pkg.Name, Import: imp}] = struct{}{}
+				}
+			}
+		}
+	}
+	return imports
+}
+
+// requiresImport tells if the import path belongs to one of the modules required in go.mod.
+func (m *Module) requiresImport(imp string) bool {
+	for dep := range m.requires {
+		if imp == dep || strings.HasPrefix(imp, dep+"/") {
+			return true
+		}
+	}
+	return false
+}
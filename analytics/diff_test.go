@@ -0,0 +1,75 @@
+package analytics
+
+import (
+	"os"
+	"testing"
+)
+
+func loadModule(t *testing.T, files map[string]string) *Module {
+	t.Helper()
+	m := writeModule(t, "example.com/app", files)
+	if err := m.parseModFS(os.DirFS(m.Location)); err != nil {
+		t.Fatalf("parseModFS: %v", err)
+	}
+	if err := m.LoadSource(); err != nil {
+		t.Fatalf("LoadSource: %v", err)
+	}
+	return m
+}
+
+func TestDiff(t *testing.T) {
+	from := loadModule(t, map[string]string{
+		"go.mod":       "module example.com/app\n\nrequire (\n\texample.com/a v1.0.0\n\texample.com/b v1.0.0\n)\n",
+		"main.go":      "package main\n\nimport \"example.com/a\"\n\nfunc main() { a.Run() }\n",
+		"old/old.go":   "package old\n",
+		"same/same.go": "package same\n",
+	})
+	to := loadModule(t, map[string]string{
+		"go.mod": "module example.com/app\n\nrequire (\n\texample.com/a v1.1.0\n\texample.com/c v0.1.0\n)\n",
+		"main.go": "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/a\"\n\t\"example.com/c/sub\"\n)\n\n" +
+			"func main() {\n\tif a.Run() {\n\t\tfmt.Println(sub.X)\n\t}\n}\n",
+		"new/new.go":   "package new\n",
+		"same/same.go": "package same\n",
+	})
+	d := Diff(from, to)
+	if len(d.AddedPackages) != 1 || d.AddedPackages[0] != "new" {
+		t.Errorf("AddedPackages = %v, want [new]", d.AddedPackages)
+	}
+	if len(d.RemovedPackages) != 1 || d.RemovedPackages[0] != "old" {
+		t.Errorf("RemovedPackages = %v, want [old]", d.RemovedPackages)
+	}
+	wantDeps := []DependencyChange{
+		{Path: "example.com/a", From: "v1.0.0", To: "v1.1.0"},
+		{Path: "example.com/b", From: "v1.0.0"},
+		{Path: "example.com/c", To: "v0.1.0"},
+	}
+	if len(d.Dependencies) != len(wantDeps) {
+		t.Fatalf("Dependencies = %v, want %v", d.Dependencies, wantDeps)
+	}
+	for i, want := range wantDeps {
+		if d.Dependencies[i] != want {
+			t.Errorf("Dependencies[%d] = %v, want %v", i, d.Dependencies[i], want)
+		}
+	}
+	if len(d.NewImports) != 1 || d.NewImports[0] != (CrossImport{Package: "main", Import: "example.com/c/sub"}) {
+		t.Errorf("NewImports = %v, want main importing example.com/c/sub", d.NewImports)
+	}
+	for _, p := range d.Packages {
+		switch p.Name {
+		case "main":
+			if p.LoCDelta() != 9 || p.ComplexityDelta() != 1 {
+				t.Errorf("main: LoC delta %d, complexity delta %.1f; want 9, 1", p.LoCDelta(), p.ComplexityDelta())
+			}
+		case "same":
+			if p.LoCDelta() != 0 || p.ComplexityDelta() != 0 {
+				t.Errorf("same: unexpected delta %+v", p)
+			}
+		}
+	}
+	if d.Empty() {
+		t.Error("Empty() = true, want false")
+	}
+	if !Diff(to, to).Empty() {
+		t.Error("diffing a module with itself should be empty")
+	}
+}
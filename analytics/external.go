@@ -0,0 +1,29 @@
+package analytics
+
+import (
+	"log/slog"
+	"os"
+)
+
+// loadExternalSource loads the source of an external module from the module cache, at the
+// version the local modules select. A module missing from the cache is left without source,
+// as is one that fails to load: external code is for browsing, it mustn't stop the analysis.
+func (r *Repo) loadExternalSource(mod *Module) {
+	dir, ok := r.modCacheDir(mod)
+	if !ok {
+		slog.Debug("external module not in the module cache", "module", mod.Path)
+		return
+	}
+	mod.Location = dir
+	err := mod.loadSource(os.DirFS(dir))
+	if err != nil {
+		slog.Warn("loading external module source", "module", mod.Path, "dir", dir, "error", err)
+		mod.Location, mod.Packages, mod.packageDirs, mod.fsys = "", nil, nil, nil
+	}
+}
+
+// SourceLoaded tells if the source of the module was loaded: always for local modules, from the
+// module cache for external ones.
+func (m *Module) SourceLoaded() bool {
+	return m.fsys != nil
+}
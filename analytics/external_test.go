@@ -0,0 +1,75 @@
+package analytics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadExternalSource(t *testing.T) {
+	modCache := t.TempDir()
+	files := map[string]string{
+		"gopkg.in/yaml.v3@v3.0.1/yaml.go":                 "package yaml\n\nimport \"strings\"\n\nfunc Marshal(s string) string {\n\treturn strings.ToUpper(s)\n}\n",
+		"gopkg.in/yaml.v3@v3.0.1/testdata/broken.go":      "this isn't go\n",
+		"github.com/!ext/kit@v0.2.0/log/log.go":           "package log\n\nimport \"gopkg.in/yaml.v3\"\n",
+		"github.com/!ext/kit@v0.2.0/_examples/example.go": "package main\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(modCache, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := &Repo{
+		modules:   make(map[string]*Module),
+		snapshots: make(map[string]*Module),
+		config:    Config{ModCache: modCache, LoadExternal: true},
+	}
+	svc := sumModule(t, r, "example.com/svc", "module example.com/svc\n\ngo 1.22\n\nrequire (\n"+
+		"\tgithub.com/Ext/kit v0.2.0\n\tgopkg.in/yaml.v3 v3.0.1\n\texample.com/missing v1.0.0\n)\n", "")
+	if err := os.WriteFile(filepath.Join(svc.Location, "main.go"), []byte("package main\n\nimport (\n\t\"github.com/Ext/kit/log\"\n\t\"gopkg.in/yaml.v3\"\n)\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"github.com/Ext/kit", "gopkg.in/yaml.v3", "example.com/missing"} {
+		r.modules[path] = &Module{Path: path, Type: DepTypeExternal, Repo: r}
+	}
+	// the local module is loaded before its dependencies, imports are resolved afterwards:
+	if err := svc.LoadSource(); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"github.com/Ext/kit", "gopkg.in/yaml.v3", "example.com/missing"} {
+		r.loadExternalSource(r.modules[path])
+	}
+	for _, mod := range r.modules {
+		mod.resolveImports()
+	}
+
+	yaml, kit, missing := r.modules["gopkg.in/yaml.v3"], r.modules["github.com/Ext/kit"], r.modules["example.com/missing"]
+	if !yaml.SourceLoaded() || len(yaml.Packages) != 1 || yaml.Files() != 1 || yaml.Lines() != 7 {
+		t.Errorf("yaml.v3: %d packages, %d files, %d lines", len(yaml.Packages), yaml.Files(), yaml.Lines())
+	}
+	if len(kit.Packages) != 1 || kit.Packages[0].Name != "log" {
+		t.Errorf("kit packages: %+v", kit.Packages)
+	}
+	if missing.SourceLoaded() || len(missing.Packages) != 0 {
+		t.Errorf("module missing from the cache has source")
+	}
+	main, _ := svc.GetPackage("main")
+	imports := main.GetFiles()[0].Imports
+	if len(imports) != 2 || imports[0] != kit.Packages[0] || imports[1] != yaml.Packages[0] {
+		t.Errorf("imports of main: %+v", imports)
+	}
+	if imports := kit.Packages[0].GetFiles()[0].Imports; len(imports) != 1 || imports[0].Module != yaml {
+		t.Errorf("imports of kit/log: %+v", imports)
+	}
+	g, err := r.PackageGraph("github.com/Ext/kit", "log", GraphOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Edges) != 1 || g.Edges[0] != (GraphEdge{From: "github.com/Ext/kit#log", To: "gopkg.in/yaml.v3#yaml"}) {
+		t.Errorf("graph of an external package: %+v", g)
+	}
+}
@@ -0,0 +1,261 @@
+package analytics
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/boltdb/bolt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// factsVersion must be bumped whenever fileFacts or the way they are extracted changes, it
+// invalidates the facts cached by earlier versions.
+const factsVersion = 6
+
+var (
+	factsBucket  = []byte("facts")  // facts by content key, see contentKey
+	stampsBucket = []byte("stamps") // content key by file path, modification time and size
+	metaBucket   = []byte("meta")
+	versionKey   = []byte("version")
+)
+
+// factCache keeps file facts on disk between runs. Facts are looked up by content, so a file
+// is only parsed again when it changes. Files in the working tree are also looked up by path,
+// modification time and size, which saves reading unchanged files at all. Writes are buffered
+// and committed in one transaction by flush. A nil cache caches nothing.
+type factCache struct {
+	db      *bolt.DB
+	mu      sync.Mutex
+	pending map[string][]byte // facts by content key
+	stamps  map[string]string // content key by stamp
+}
+
+// stamp identifies a version of a file in the working tree without reading it.
+type stamp struct {
+	Path    string
+	ModTime int64 // nanoseconds since the epoch
+	Size    int64
+}
+
+func (s stamp) key() string {
+	return s.Path + "\x00" + strconv.FormatInt(s.ModTime, 10) + "\x00" + strconv.FormatInt(s.Size, 10)
+}
+
+// lockTimeout is how long openFactCache waits for another process, like a running server,
+// to release the cache.
+const lockTimeout = time.Second
+
+// openFactCache opens the cache, dropping its contents if they were written by another
+// version of the facts.
+func openFactCache(path string) (*factCache, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: lockTimeout})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("%s is in use by another process, like a running gogrok server, use another cache file or none: %w", path, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("bolt.Open: %w", err)
+	}
+	version := []byte(strconv.Itoa(factsVersion))
+	err = db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return err
+		}
+		if string(meta.Get(versionKey)) != string(version) {
+			for _, name := range [][]byte{factsBucket, stampsBucket} {
+				if tx.Bucket(name) != nil {
+					if err := tx.DeleteBucket(name); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		for _, name := range [][]byte{factsBucket, stampsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return meta.Put(versionKey, version)
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("db.Update: %w", err)
+	}
+	return &factCache{
+		db:      db,
+		pending: make(map[string][]byte),
+		stamps:  make(map[string]string),
+	}, nil
+}
+
+// contentKey is the cache key of a file's facts. It includes the file type derived from the
+// name, since the same content is counted differently in a test file.
+func contentKey(name string, src []byte) string {
+	sum := sha256.Sum256(src)
+	return strconv.Itoa(int(NameToFileType(name))) + ":" + hex.EncodeToString(sum[:])
+}
+
+// byStamp returns the cached facts of an unchanged file in the working tree.
+func (c *factCache) byStamp(st stamp) (*fileFacts, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key, ok := c.stamps[st.key()]
+	if !ok {
+		_ = c.db.View(func(tx *bolt.Tx) error {
+			if v := tx.Bucket(stampsBucket).Get([]byte(st.key())); v != nil {
+				key, ok = string(v), true
+			}
+			return nil
+		})
+	}
+	if !ok {
+		return nil, false
+	}
+	return c.lookup(key)
+}
+
+// get returns the cached facts for a content key.
+func (c *factCache) get(key string) (*fileFacts, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lookup(key)
+}
+
+func (c *factCache) lookup(key string) (*fileFacts, bool) {
+	data, ok := c.pending[key]
+	if !ok {
+		_ = c.db.View(func(tx *bolt.Tx) error {
+			if v := tx.Bucket(factsBucket).Get([]byte(key)); v != nil {
+				data, ok = append([]byte(nil), v...), true
+			}
+			return nil
+		})
+	}
+	if !ok {
+		return nil, false
+	}
+	var facts fileFacts
+	if err := json.Unmarshal(data, &facts); err != nil {
+		return nil, false // treat as a cache miss
+	}
+	return &facts, true
+}
+
+// put stores the facts for a content key, and remembers the stamp of the file if it's in
+// the working tree. Nothing is written until flush.
+func (c *factCache) put(key string, facts *fileFacts, st *stamp) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if facts != nil {
+		data, err := json.Marshal(facts)
+		if err != nil {
+			slog.Warn("fact cache marshal", "error", err)
+			return
+		}
+		c.pending[key] = data
+	}
+	if st != nil {
+		c.stamps[st.key()] = key
+	}
+}
+
+// flush writes the buffered facts and stamps to disk. A new stamp replaces the earlier
+// stamps of the same path, and the facts only those referred to are deleted. Facts no stamp
+// ever referred to, like those of files in git history, are kept.
+func (c *factCache) flush() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		facts, stamps := tx.Bucket(factsBucket), tx.Bucket(stampsBucket)
+		for key, data := range c.pending {
+			if err := facts.Put([]byte(key), data); err != nil {
+				return err
+			}
+		}
+		orphans := make(map[string]bool)
+		for st, key := range c.stamps {
+			replaced, err := pruneStamps(stamps, st)
+			if err != nil {
+				return err
+			}
+			for _, old := range replaced {
+				orphans[old] = true
+			}
+			if err := stamps.Put([]byte(st), []byte(key)); err != nil {
+				return err
+			}
+		}
+		if len(orphans) == 0 {
+			return nil
+		}
+		err := stamps.ForEach(func(_, key []byte) error {
+			delete(orphans, string(key))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for key := range orphans {
+			if _, ok := c.pending[key]; ok {
+				continue
+			}
+			if err := facts.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("db.Update: %w", err)
+	}
+	c.pending = make(map[string][]byte)
+	c.stamps = make(map[string]string)
+	return nil
+}
+
+// pruneStamps deletes the stamps of the same path as st, other than st itself, and returns
+// the content keys they referred to.
+func pruneStamps(stamps *bolt.Bucket, st string) ([]string, error) {
+	prefix := []byte(st[:strings.IndexByte(st, 0)+1])
+	var stale [][]byte
+	var keys []string
+	cur := stamps.Cursor()
+	for k, v := cur.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = cur.Next() {
+		if string(k) != st {
+			stale = append(stale, append([]byte(nil), k...))
+			keys = append(keys, string(v))
+		}
+	}
+	for _, k := range stale {
+		if err := stamps.Delete(k); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+func (c *factCache) close() error {
+	if c == nil {
+		return nil
+	}
+	return c.db.Close()
+}
@@ -0,0 +1,122 @@
+package analytics
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFactCache(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "facts.db")
+	files := map[string]string{
+		"calc.go":      "package calc\n\nfunc Abs(a int) int {\n\tif a < 0 {\n\t\treturn -a\n\t}\n\treturn a\n}\n",
+		"calc_test.go": "package calc_test\n\nimport \"testing\"\n\nfunc TestAbs(t *testing.T) {}\n",
+	}
+	load := func(m *Module) {
+		t.Helper()
+		cache, err := openFactCache(dbFile)
+		if err != nil {
+			t.Fatalf("openFactCache: %v", err)
+		}
+		defer cache.close()
+		m.Repo.cache = cache
+		if err := m.LoadSource(); err != nil {
+			t.Fatalf("LoadSource: %v", err)
+		}
+	}
+	first := writeModule(t, "example.com/calc", files)
+	load(first)
+
+	// the same files elsewhere are found by content:
+	second := writeModule(t, "example.com/calc", files)
+	cache, err := openFactCache(dbFile)
+	if err != nil {
+		t.Fatalf("openFactCache: %v", err)
+	}
+	src, err := os.ReadFile(filepath.Join(second.Location, "calc.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.get(contentKey("calc.go", src)); !ok {
+		t.Error("facts of calc.go should be cached by content")
+	}
+	// and unchanged files in the same place by their stamp:
+	info, err := os.Stat(filepath.Join(first.Location, "calc.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := stamp{Path: filepath.Join(first.Location, "calc.go"), ModTime: info.ModTime().UnixNano(), Size: info.Size()}
+	if _, ok := cache.byStamp(st); !ok {
+		t.Error("facts of calc.go should be cached by stamp")
+	}
+	_ = cache.close()
+
+	load(second)
+	calc, ok := second.GetPackage("calc")
+	if !ok {
+		t.Fatal("package calc not found")
+	}
+	fns := calc.Functions()
+	if len(fns) != 1 || fns[0].CalculateComplexity() != 2 || fns[0].StartLine != 3 {
+		t.Errorf("cached functions = %+v, want Abs with complexity 2 at line 3", fns)
+	}
+	if calc.Lines() != 8 || len(calc.TestFunctions()) != 1 {
+		t.Errorf("cached package: %d lines, %d tests; want 8, 1", calc.Lines(), len(calc.TestFunctions()))
+	}
+	if got := second.Packages[0].files[0].GetSource(); len(got) == 0 {
+		t.Error("GetSource() should read the source from disk")
+	}
+}
+
+func TestFactCachePrune(t *testing.T) {
+	cache, err := openFactCache(filepath.Join(t.TempDir(), "facts.db"))
+	if err != nil {
+		t.Fatalf("openFactCache: %v", err)
+	}
+	defer cache.close()
+	flush := func() {
+		t.Helper()
+		if err := cache.flush(); err != nil {
+			t.Fatalf("flush: %v", err)
+		}
+	}
+	facts := &fileFacts{}
+	cache.put("v1", facts, &stamp{Path: "/src/a.go", ModTime: 1, Size: 10})
+	cache.put("shared", facts, &stamp{Path: "/src/b.go", ModTime: 1, Size: 10})
+	cache.put("history", facts, nil)
+	flush()
+	// a.go changes twice, and its second version is the same as b.go:
+	cache.put("v2", facts, &stamp{Path: "/src/a.go", ModTime: 2, Size: 11})
+	flush()
+	cache.put("shared", facts, &stamp{Path: "/src/a.go", ModTime: 3, Size: 10})
+	flush()
+
+	for key, want := range map[string]bool{"v1": false, "v2": false, "shared": true, "history": true} {
+		if _, ok := cache.get(key); ok != want {
+			t.Errorf("facts %s cached = %v, want %v", key, ok, want)
+		}
+	}
+	for st, want := range map[stamp]bool{
+		{Path: "/src/a.go", ModTime: 1, Size: 10}: false,
+		{Path: "/src/a.go", ModTime: 2, Size: 11}: false,
+		{Path: "/src/a.go", ModTime: 3, Size: 10}: true,
+		{Path: "/src/b.go", ModTime: 1, Size: 10}: true,
+	} {
+		if _, ok := cache.byStamp(st); ok != want {
+			t.Errorf("stamp %+v cached = %v, want %v", st, ok, want)
+		}
+	}
+}
+
+func TestFactCacheInUse(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "facts.db")
+	cache, err := openFactCache(dbFile)
+	if err != nil {
+		t.Fatalf("openFactCache: %v", err)
+	}
+	defer cache.close()
+	if _, err := openFactCache(dbFile); err == nil || !strings.Contains(err.Error(), "in use") {
+		t.Errorf("opening a cache in use: got %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package analytics
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// fileFacts is everything the analysis needs from a source file. The facts are extracted from
+// the AST once and can be cached, so unchanged files don't have to be parsed again, and
+// neither the AST nor the source is kept in memory.
+type fileFacts struct {
+	Package    string // package name, external test packages (foo_test) are accounted to foo
+	Type       FileType
+	Lines      int
+	Imports    []string
+	Complexity float32
+	Functions  []functionFacts      // functions and methods with a body, in source order
+	API        map[string]apiSymbol // exported symbols, not extracted for test files
+	Deprecated []deprecatedSymbol   // exported symbols marked as deprecated, not extracted for test files
+	// PackageDeprecated is the Deprecated: paragraph of the package doc comment
+	PackageDeprecated string
+	ImportSpecs       []importSpec
+	Refs              []symbolRef  // uses of exported identifiers of imported packages
+	Risks             []riskFacts  // risky constructs, not extracted for test files
+	Flags             []flagFacts  // command line flags defined, not extracted for test files
+	Routes            []routeFacts // HTTP routes registered, not extracted for test files
+}
+
+type functionFacts struct {
+	Name       string
+	Receiver   string
+	StartLine  int
+	EndLine    int
+	Complexity float32
+}
+
+// extractFacts parses a file and extracts its facts. The name is only used for the file type
+// and error messages.
+func extractFacts(name string, src []byte) (*fileFacts, error) {
+	fset := token.NewFileSet()
+	astFile, err := parser.ParseFile(fset, name, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := splitLines(src)
+	if err != nil {
+		return nil, err
+	}
+	facts := &fileFacts{
+		Package: astFile.Name.Name,
+		Type:    NameToFileType(name),
+		Lines:   len(lines),
+		Imports: make([]string, 0, len(astFile.Imports)),
+	}
+	if facts.Type == TestGo {
+		facts.Package = strings.TrimSuffix(facts.Package, "_test")
+	}
+	// try to detect if the file is generated
+	if facts.Type == HumanGo && len(lines) > 5 {
+		for _, line := range lines[:5] {
+			if strings.Contains(line, "Code generated") {
+				facts.Type = GeneratedGo
+				break
+			}
+		}
+	}
+	for _, imp := range astFile.Imports {
+		p, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		facts.Imports = append(facts.Imports, p)
+	}
+	v := &complexityVisitor{}
+	ast.Walk(v, astFile)
+	facts.Complexity = float32(v.complexity) + 1 // Adding 1 for the entry point
+	facts.Functions = extractFunctions(astFile, fset)
+	if facts.Type != TestGo {
+		facts.API = extractAPI(astFile)
+		facts.Deprecated = extractDeprecated(astFile, fset)
+		facts.PackageDeprecated, _ = deprecationNotice(astFile.Doc)
+		facts.Risks = extractRisks(astFile, fset)
+		facts.Flags = extractFlags(astFile, fset)
+		facts.Routes = extractRoutes(astFile, fset)
+	}
+	facts.ImportSpecs = extractImports(astFile, fset)
+	facts.Refs = extractRefs(astFile, fset, facts.ImportSpecs)
+	return facts, nil
+}
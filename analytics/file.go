@@ -2,51 +2,36 @@ package analytics
 
 import (
 	"bufio"
+	"bytes"
 	"go/ast"
 	"go/token"
+	"io/fs"
 	"log/slog"
-	"os"
 	"path"
+	"slices"
 	"strings"
 )
 
-// AddFile adds a file to the package
-func (p *Package) AddFile(name string, file *ast.File) *File {
+// addFile adds a file to the package. The name is the slash separated path of the file
+// relative to the module.
+func (p *Package) addFile(name string, facts *fileFacts) *File {
 	for _, file := range p.files {
-		if file.Name == name {
+		if file.Path == name {
 			// should never happen
 			panic("file already exists")
 		}
 	}
-	//
-	lines, err := readFile(name)
-	if err != nil {
-		// should never happen
-		panic("readFile: " + err.Error())
-
-	}
-	fileType := NameToFileType(name)
-	// try to detect if the file is generated
-
-	if fileType == HumanGo && len(lines) > 5 {
-		for _, line := range lines[:5] {
-			if strings.Contains(line, "Code generated") {
-				fileType = GeneratedGo
-				break
-			}
-		}
-	}
-
 	// create a new file:
 	f := &File{
 		Name:    path.Base(name),
+		Path:    name,
 		Imports: make([]*Package, 0),
 		Package: p,
 		Module:  p.Module,
-		source:  lines,
-		ast:     file,
-		Type:    fileType,
+		Type:    facts.Type,
+		facts:   facts,
 	}
+	f.functions = newFunctions(f)
 	p.files = append(p.files, f)
 	return f
 }
@@ -55,11 +40,6 @@ func (p *Package) AddFile(name string, file *ast.File) *File {
 // it should find the module we're importing from and the package
 // and add it to the file's imports
 func (f *File) AddImport(name string) {
-	for _, pkg := range f.Imports {
-		if pkg.Name == name {
-			panic("import already exists")
-		}
-	}
 	// ignore if this in an internal import within the same module:
 	if strings.HasPrefix(name, f.Module.Path) {
 		return
@@ -68,23 +48,45 @@ func (f *File) AddImport(name string) {
 	mName, ok := f.Module.Repo.FindModule(name)
 	if !ok {
 		// The modules we can't locate are either stdlib or external, not our code.
+		if f.Module.stdlibImport(name) && name != "C" {
+			if p, ok := f.Module.Repo.stdlibPackage(name, f.Module.Ref == ""); ok {
+				f.addImported(p)
+			}
+		}
 		return
 	}
 	mod, ok := f.Module.Repo.GetModule(mName)
 	if !ok {
 		panic("GetModule failed " + mName)
 	}
-	// skip if the package isn't local:
-	if mod.Type != DepTypeLocal {
-		return
-	}
-	pack := packageNameFromImportPath(name)
-	p, ok := mod.GetPackage(pack)
+	// external modules only have packages if their source was loaded:
+	p, ok := mod.importedPackage(name)
 	if !ok {
 		// package is liked missing from the latest versions of the module, we just ignore it
 		return
 	}
-	f.Imports = append(f.Imports, p)
+	f.addImported(p)
+}
+
+// addImported adds a package to the imports of the file, once. A file can import a path
+// twice, under different names.
+func (f *File) addImported(p *Package) {
+	if !slices.Contains(f.Imports, p) {
+		f.Imports = append(f.Imports, p)
+	}
+}
+
+// importedPackage finds the package of the module an import path refers to, by the directory
+// it's in, or by name for modules built without loading source.
+func (m *Module) importedPackage(imp string) (*Package, bool) {
+	dir := strings.TrimPrefix(strings.TrimPrefix(imp, m.Path), "/")
+	if dir == "" {
+		dir = "."
+	}
+	if p, ok := m.packageDirs[dir]; ok {
+		return p, true
+	}
+	return m.GetPackage(packageNameFromImportPath(imp))
 }
 
 func packageNameFromImportPath(path string) string {
@@ -93,17 +95,10 @@ func packageNameFromImportPath(path string) string {
 	return parts[len(parts)-1]
 }
 
-// readFile reads a file and return a list of lines
-func readFile(path string) ([]string, error) {
-	// Open the file for reading.
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, err // Return the error if the file cannot be opened.
-	}
-	defer file.Close() // Ensure the file is closed after this function completes.
-
+// splitLines splits the contents of a file into a list of lines
+func splitLines(src []byte) ([]string, error) {
 	var lines []string
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(bytes.NewReader(src))
 	for scanner.Scan() {
 		lines = append(lines, scanner.Text()) // Add the line to the lines slice.
 	}
@@ -116,11 +111,29 @@ func readFile(path string) ([]string, error) {
 }
 
 func (f *File) Lines() int {
-	return len(f.source)
+	if f.facts == nil {
+		return 0
+	}
+	return f.facts.Lines
 }
 
+// GetSource returns the lines of the file. The source isn't kept in memory, it is read again
+// from the working tree or git snapshot the module was loaded from.
 func (f *File) GetSource() []string {
-	return f.source
+	if f.Module.fsys == nil {
+		return nil
+	}
+	src, err := fs.ReadFile(f.Module.fsys, f.Path)
+	if err != nil {
+		slog.Warn("reading source", "module", f.Module.Path, "file", f.Path, "error", err)
+		return nil
+	}
+	lines, err := splitLines(src)
+	if err != nil {
+		slog.Warn("reading source", "module", f.Module.Path, "file", f.Path, "error", err)
+		return nil
+	}
+	return lines
 }
 
 // complexityVisitor implements the ast.Visitor interface, counting decision points.
@@ -150,15 +163,16 @@ func (v *complexityVisitor) Visit(n ast.Node) ast.Visitor {
 	return v
 }
 
-// CalculateComplexity walks the AST of a Go file to calculate its cyclomatic complexity.
+// CalculateComplexity returns the cyclomatic complexity of a Go file.
 func (f *File) CalculateComplexity() float32 {
 	if f.Type == GeneratedGo {
 		slog.Info("complex skipping generated file", "file", f.Name)
 		return 1.0
 	}
-	v := &complexityVisitor{}
-	ast.Walk(v, f.ast)
-	return float32(v.complexity) + 1 // Adding 1 for the entry point
+	if f.facts == nil {
+		return 1.0
+	}
+	return f.facts.Complexity
 }
 
 func NameToFileType(name string) FileType {
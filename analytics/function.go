@@ -0,0 +1,100 @@
+package analytics
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+)
+
+// Function is a function or method declared in a file.
+type Function struct {
+	Name       string // function name
+	Receiver   string // receiver type for methods, ie. "*Repo", empty for functions
+	File       *File  // reference to the file the function is declared in
+	StartLine  int
+	EndLine    int
+	complexity float32
+}
+
+// String returns the function name, qualified with the receiver for methods.
+func (fn *Function) String() string {
+	if fn.Receiver == "" {
+		return fn.Name
+	}
+	return fmt.Sprintf("(%s).%s", fn.Receiver, fn.Name)
+}
+
+// CalculateComplexity returns the cyclomatic complexity of the function.
+func (fn *Function) CalculateComplexity() float32 {
+	return fn.complexity
+}
+
+// Functions returns the functions and methods declared in the file, in source order.
+func (f *File) Functions() []*Function {
+	return f.functions
+}
+
+// newFunctions creates the functions of a file from its facts.
+func newFunctions(f *File) []*Function {
+	funcs := make([]*Function, 0, len(f.facts.Functions))
+	for _, ff := range f.facts.Functions {
+		funcs = append(funcs, &Function{
+			Name:       ff.Name,
+			Receiver:   ff.Receiver,
+			File:       f,
+			StartLine:  ff.StartLine,
+			EndLine:    ff.EndLine,
+			complexity: ff.Complexity,
+		})
+	}
+	return funcs
+}
+
+// extractFunctions finds the functions and methods with a body in a file.
+func extractFunctions(file *ast.File, fset *token.FileSet) []functionFacts {
+	funcs := make([]functionFacts, 0)
+	for _, decl := range file.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok || fd.Body == nil {
+			continue
+		}
+		v := &complexityVisitor{}
+		ast.Walk(v, fd)
+		fn := functionFacts{
+			Name:       fd.Name.Name,
+			StartLine:  fset.Position(fd.Pos()).Line,
+			EndLine:    fset.Position(fd.End()).Line,
+			Complexity: float32(v.complexity) + 1,
+		}
+		if fd.Recv != nil && len(fd.Recv.List) > 0 {
+			fn.Receiver = receiverType(fd.Recv.List[0].Type)
+		}
+		funcs = append(funcs, fn)
+	}
+	return funcs
+}
+
+// receiverType renders the receiver type expression, dropping type parameters.
+func receiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return "*" + receiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	case *ast.IndexExpr:
+		return receiverType(t.X)
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
+	default:
+		return "?"
+	}
+}
+
+// Functions returns the functions of all production files in the package.
+func (p *Package) Functions() []*Function {
+	funcs := make([]*Function, 0)
+	for _, file := range p.countedFiles() {
+		funcs = append(funcs, file.Functions()...)
+	}
+	return funcs
+}
@@ -0,0 +1,210 @@
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/mod/semver"
+	"io/fs"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Requirement is a module required by the go.mod of a local module.
+type Requirement struct {
+	Path    string
+	Version string
+	Sum     string // h1: hash of the module content from go.sum, empty if go.sum has none
+	License string // SPDX license expression of the version the local modules select, empty if unknown
+}
+
+// SumEntry is a line of a go.sum file.
+type SumEntry struct {
+	Path    string
+	Version string
+	GoMod   bool   // the hash is of the go.mod file alone, not the module content
+	Hash    string // h1:...
+}
+
+// String formats the entry like the first two fields of a go.sum line.
+func (e SumEntry) String() string {
+	if e.GoMod {
+		return e.Path + " " + e.Version + "/go.mod"
+	}
+	return e.Path + " " + e.Version
+}
+
+// Requirements returns the modules required by the go.mod of the module, direct and indirect,
+// with their hashes from go.sum, sorted by path.
+func (m *Module) Requirements() []Requirement {
+	sums := make(map[string]string, len(m.goSum))
+	for _, e := range m.goSum {
+		if !e.GoMod {
+			sums[e.Path+"@"+e.Version] = e.Hash
+		}
+	}
+	reqs := make([]Requirement, 0, len(m.requires))
+	for path, version := range m.requires {
+		req := Requirement{Path: path, Version: version, Sum: sums[path+"@"+version]}
+		if dep, ok := m.Repo.GetModule(path); ok {
+			req.License = dep.license.Expression()
+		}
+		reqs = append(reqs, req)
+	}
+	sort.Slice(reqs, func(i, j int) bool {
+		return reqs[i].Path < reqs[j].Path
+	})
+	return reqs
+}
+
+// GoSum returns the entries of the go.sum of the module, in file order.
+func (m *Module) GoSum() []SumEntry {
+	return m.goSum
+}
+
+// SumCheck is what's wrong with the go.sum of a local module.
+type SumCheck struct {
+	Module  *Module
+	NoGoSum bool          // go.mod requires modules, but there is no go.sum
+	Missing []Requirement // required by go.mod without any go.sum entry, a broken checkout
+	Stale   []SumEntry    // module content hashes of modules that aren't in the module graph
+	Invalid []int         // numbers of the go.sum lines that couldn't be parsed
+}
+
+// OK tells if nothing is wrong.
+func (c SumCheck) OK() bool {
+	return !c.NoGoSum && len(c.Missing) == 0 && len(c.Stale) == 0 && len(c.Invalid) == 0
+}
+
+// CheckSums compares the go.sum of the module with its go.mod. Every required module version
+// needs an entry, either a content or a go.mod hash. go mod tidy keeps content hashes of
+// modules go.mod doesn't require, like the test dependencies of dependencies, for the checks
+// of ambiguous imports, and go.mod hashes for the whole module graph. So only a content hash
+// of a module that is neither required nor has a go.mod hash, which is in nobody's module
+// graph, is stale. Modules declaring a go version before 1.17 aren't checked for stale entries.
+func (m *Module) CheckSums() SumCheck {
+	c := SumCheck{Module: m, NoGoSum: !m.hasGoSum && len(m.requires) > 0, Invalid: m.badSumLines}
+	summed := make(map[string]bool, len(m.goSum))
+	for _, e := range m.goSum {
+		summed[e.Path+"@"+e.Version] = true
+	}
+	for _, req := range m.Requirements() {
+		if !summed[req.Path+"@"+req.Version] {
+			c.Missing = append(c.Missing, req)
+		}
+	}
+	if m.goVersion == "" || semver.Compare("v"+m.goVersion, "v1.17") < 0 {
+		return c
+	}
+	inGraph := make(map[string]bool, len(m.requires))
+	for path := range m.requires {
+		inGraph[path] = true
+	}
+	for _, e := range m.goSum {
+		if e.GoMod {
+			inGraph[e.Path] = true
+		}
+	}
+	for _, e := range m.goSum {
+		if !e.GoMod && !inGraph[e.Path] {
+			c.Stale = append(c.Stale, e)
+		}
+	}
+	return c
+}
+
+// SumChecks returns the go.sum problems of the local modules, leaving out the modules without
+// problems.
+func (r *Repo) SumChecks() []SumCheck {
+	checks := make([]SumCheck, 0)
+	for _, mod := range r.modules {
+		if mod.Type != DepTypeLocal {
+			continue
+		}
+		if c := mod.CheckSums(); !c.OK() {
+			checks = append(checks, c)
+		}
+	}
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].Module.Path < checks[j].Module.Path
+	})
+	return checks
+}
+
+// SumConflict is a module version with different hashes in the go.sum files of the local
+// modules, which go itself would refuse. It is a sign of tampering, or of a proxy serving
+// something else than the checksum database knows.
+type SumConflict struct {
+	Entry   SumEntry            // the hash is left out
+	Modules map[string][]string // the local modules having each hash in their go.sum
+}
+
+// SumConflicts finds the module versions whose hashes differ between the go.sum files of the
+// local modules, or within one of them.
+func (r *Repo) SumConflicts() []SumConflict {
+	seen := make(map[SumEntry]map[string][]string)
+	for _, mod := range r.modules {
+		if mod.Type != DepTypeLocal {
+			continue
+		}
+		for _, e := range mod.goSum {
+			key := SumEntry{Path: e.Path, Version: e.Version, GoMod: e.GoMod}
+			if seen[key] == nil {
+				seen[key] = make(map[string][]string)
+			}
+			if !slices.Contains(seen[key][e.Hash], mod.Path) {
+				seen[key][e.Hash] = append(seen[key][e.Hash], mod.Path)
+			}
+		}
+	}
+	conflicts := make([]SumConflict, 0)
+	for key, hashes := range seen {
+		if len(hashes) < 2 {
+			continue
+		}
+		for _, mods := range hashes {
+			sort.Strings(mods)
+		}
+		conflicts = append(conflicts, SumConflict{Entry: key, Modules: hashes})
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].Entry.String() < conflicts[j].Entry.String()
+	})
+	return conflicts
+}
+
+// readGoSum reads the go.sum next to the go.mod of the module. A module without dependencies
+// has no go.sum.
+func (m *Module) readGoSum(fsys fs.FS) error {
+	m.goSum, m.badSumLines, m.hasGoSum = nil, nil, false
+	data, err := fs.ReadFile(fsys, "go.sum")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("fs.ReadFile: %w", err)
+	}
+	m.goSum, m.badSumLines = parseGoSum(data)
+	m.hasGoSum = true
+	return nil
+}
+
+// parseGoSum parses the lines of a go.sum: module path, version, optionally suffixed with
+// /go.mod, and hash. Lines that don't look like that are returned by line number, a go.sum
+// is usually broken by a merge conflict.
+func parseGoSum(data []byte) (entries []SumEntry, malformed []int) {
+	entries = make([]SumEntry, 0)
+	for n, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			malformed = append(malformed, n+1)
+			continue
+		}
+		version, goMod := strings.CutSuffix(fields[1], "/go.mod")
+		entries = append(entries, SumEntry{Path: fields[0], Version: version, GoMod: goMod, Hash: fields[2]})
+	}
+	return entries, malformed
+}
@@ -0,0 +1,111 @@
+package analytics
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+// sumModule writes a module with a go.mod and a go.sum, and reads them.
+func sumModule(t *testing.T, r *Repo, path, goMod, goSum string) *Module {
+	t.Helper()
+	files := map[string]string{"go.mod": goMod}
+	if goSum != "" {
+		files["go.sum"] = goSum
+	}
+	m := writeModule(t, path, files)
+	m.Repo = r
+	r.modules[path] = m
+	if err := m.parseModFS(os.DirFS(m.Location)); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestRequirements(t *testing.T) {
+	r := &Repo{modules: make(map[string]*Module), snapshots: make(map[string]*Module)}
+	m := sumModule(t, r, "example.com/svc",
+		"module example.com/svc\n\ngo 1.22\n\nrequire (\n\texample.com/lib v1.0.0\n\texample.com/old v0.1.0 // indirect\n)\n",
+		"example.com/lib v1.0.0 h1:lib=\nexample.com/lib v1.0.0/go.mod h1:libmod=\n\n"+
+			"example.com/old v0.1.0/go.mod h1:oldmod=\n")
+	got := m.Requirements()
+	want := []Requirement{
+		{Path: "example.com/lib", Version: "v1.0.0", Sum: "h1:lib="},
+		{Path: "example.com/old", Version: "v0.1.0"},
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if c := m.CheckSums(); !c.OK() {
+		t.Errorf("tidy go.sum has problems: %+v", c)
+	}
+}
+
+func TestCheckSums(t *testing.T) {
+	r := &Repo{modules: make(map[string]*Module), snapshots: make(map[string]*Module)}
+	goMod := "module example.com/svc\n\ngo 1.21\n\nrequire (\n\texample.com/lib v1.1.0\n\texample.com/gone v0.1.0\n)\n"
+	m := sumModule(t, r, "example.com/svc", goMod,
+		"example.com/lib v1.1.0 h1:lib=\n"+
+			"example.com/lib v1.0.0 h1:old=\n"+ // a version in the module graph
+			"example.com/lib v1.0.0/go.mod h1:oldmod=\n"+
+			"example.com/testdep v0.3.0 h1:testdep=\n"+ // a test dependency of a dependency
+			"example.com/testdep v0.3.0/go.mod h1:testdepmod=\n"+
+			"example.com/other v0.2.0 h1:other=\n"+ // in nobody's module graph
+			"<<<<<<< HEAD\n")
+	c := m.CheckSums()
+	if len(c.Missing) != 1 || c.Missing[0].Path != "example.com/gone" {
+		t.Errorf("missing: %+v", c.Missing)
+	}
+	if len(c.Stale) != 1 || c.Stale[0].String() != "example.com/other v0.2.0" {
+		t.Errorf("stale: %+v", c.Stale)
+	}
+	if len(c.Invalid) != 1 || c.Invalid[0] != 7 {
+		t.Errorf("invalid lines: %v", c.Invalid)
+	}
+
+	old := sumModule(t, r, "example.com/old", "module example.com/old\n\ngo 1.16\n\nrequire example.com/lib v1.1.0\n",
+		"example.com/lib v1.1.0 h1:lib=\nexample.com/other v0.2.0 h1:other=\n")
+	if c := old.CheckSums(); !c.OK() {
+		t.Errorf("go 1.16 module: %+v", c)
+	}
+	none := sumModule(t, r, "example.com/none", "module example.com/none\n\ngo 1.22\n\nrequire example.com/lib v1.1.0\n", "")
+	if c := none.CheckSums(); !c.NoGoSum || len(c.Missing) != 1 {
+		t.Errorf("no go.sum: %+v", c)
+	}
+	if checks := r.SumChecks(); len(checks) != 2 || checks[0].Module != none || checks[1].Module != m {
+		t.Errorf("SumChecks: %+v", checks)
+	}
+}
+
+// TestCheckTidySums checks gogrok's own go.sum, which go mod tidy keeps tidy.
+func TestCheckTidySums(t *testing.T) {
+	goMod, err := os.ReadFile("../go.mod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	goSum, err := os.ReadFile("../go.sum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Repo{modules: make(map[string]*Module), snapshots: make(map[string]*Module)}
+	m := sumModule(t, r, "github.com/perbu/gogrok", string(goMod), string(goSum))
+	if c := m.CheckSums(); !c.OK() {
+		t.Errorf("tidy go.sum has problems: %d stale, %d missing: %+v", len(c.Stale), len(c.Missing), c)
+	}
+}
+
+func TestSumConflicts(t *testing.T) {
+	r := &Repo{modules: make(map[string]*Module), snapshots: make(map[string]*Module)}
+	goMod := "module %s\n\ngo 1.22\n\nrequire example.com/lib v1.1.0\n"
+	sumModule(t, r, "example.com/a", fmt.Sprintf(goMod, "example.com/a"), "example.com/lib v1.1.0 h1:good=\nexample.com/lib v1.1.0/go.mod h1:mod=\n")
+	sumModule(t, r, "example.com/b", fmt.Sprintf(goMod, "example.com/b"), "example.com/lib v1.1.0 h1:good=\nexample.com/lib v1.1.0/go.mod h1:mod=\n")
+	sumModule(t, r, "example.com/c", fmt.Sprintf(goMod, "example.com/c"), "example.com/lib v1.1.0 h1:evil=\nexample.com/lib v1.1.0/go.mod h1:mod=\n")
+	conflicts := r.SumConflicts()
+	if len(conflicts) != 1 {
+		t.Fatalf("conflicts: %+v", conflicts)
+	}
+	c := conflicts[0]
+	if c.Entry.String() != "example.com/lib v1.1.0" || len(c.Modules["h1:good="]) != 2 || c.Modules["h1:evil="][0] != "example.com/c" {
+		t.Errorf("conflict: %+v", c)
+	}
+}
@@ -0,0 +1,292 @@
+package analytics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Direction tells which way a dependency graph is walked from its root.
+type Direction int
+
+const (
+	Dependencies Direction = iota // what the root depends on
+	Dependents                    // what depends on the root
+	Both
+)
+
+// ParseDirection parses "deps", "reverse" or "both".
+func ParseDirection(s string) (Direction, error) {
+	switch s {
+	case "deps", "":
+		return Dependencies, nil
+	case "reverse":
+		return Dependents, nil
+	case "both":
+		return Both, nil
+	default:
+		return 0, fmt.Errorf("unknown direction %q, want deps, reverse or both", s)
+	}
+}
+
+// GraphOptions limits the neighbourhood of a dependency graph.
+type GraphOptions struct {
+	Depth     int // how many edges away from the root to go, 0 for no limit
+	Direction Direction
+	LocalOnly bool // leave out external modules and packages
+}
+
+// Graph is the neighbourhood of a module or package in the dependency graph. An edge goes
+// from the depending node to its dependency, whichever way the graph was walked.
+type Graph struct {
+	Root  string // empty for graphs walked from several nodes
+	Nodes []GraphNode
+	Edges []GraphEdge
+}
+
+// GraphNode is a module or package in a dependency graph.
+type GraphNode struct {
+	ID      string
+	Label   string
+	Module  string // path of the module, the node itself in module graphs
+	Package string // package name, empty in module graphs and for external packages without source
+	Local   bool
+	Depth   int    // edges from the root, negative for dependents
+	License string // SPDX license expression of the module, empty if unknown
+}
+
+// GraphEdge is a dependency between two nodes, by ID.
+type GraphEdge struct {
+	From string
+	To   string
+}
+
+// graphIndex is the whole dependency graph, which a neighbourhood is cut out of.
+type graphIndex struct {
+	nodes   map[string]GraphNode
+	forward map[string][]string
+}
+
+// ModuleGraph returns the neighbourhood of a module in the module dependency graph.
+func (r *Repo) ModuleGraph(path string, opts GraphOptions) (*Graph, error) {
+	if _, ok := r.modules[path]; !ok {
+		return nil, fmt.Errorf("module %s not found", path)
+	}
+	return r.moduleIndex(opts).neighbourhood([]string{path}, opts), nil
+}
+
+// PackageGraph returns the neighbourhood of a package in the package import graph. Local
+// packages and external packages with loaded source are walked through, other external
+// packages are leaves and the standard library is left out.
+func (r *Repo) PackageGraph(modPath, name string, opts GraphOptions) (*Graph, error) {
+	mod, ok := r.modules[modPath]
+	if !ok {
+		return nil, fmt.Errorf("module %s not found", modPath)
+	}
+	root, ok := mod.GetPackage(name)
+	if !ok {
+		return nil, fmt.Errorf("package %s not found in %s", name, modPath)
+	}
+	return r.packageIndex(opts).neighbourhood([]string{packageID(root)}, opts), nil
+}
+
+// LocalModuleGraph returns the module dependency graph from all local modules whose path
+// starts with prefix. A depth of 0 walks the whole graph. Root is only set if a single module
+// matches.
+func (r *Repo) LocalModuleGraph(prefix string, opts GraphOptions) *Graph {
+	var roots []string
+	for _, mod := range r.modules {
+		if mod.Type == DepTypeLocal && strings.HasPrefix(mod.Path, prefix) {
+			roots = append(roots, mod.Path)
+		}
+	}
+	return r.moduleIndex(opts).neighbourhood(roots, opts)
+}
+
+// LocalPackageGraph returns the package import graph from all packages of the local modules
+// whose path starts with prefix, like LocalModuleGraph.
+func (r *Repo) LocalPackageGraph(prefix string, opts GraphOptions) *Graph {
+	var roots []string
+	for _, mod := range r.modules {
+		if mod.Type != DepTypeLocal || !strings.HasPrefix(mod.Path, prefix) {
+			continue
+		}
+		for _, p := range mod.Packages {
+			roots = append(roots, packageID(p))
+		}
+	}
+	return r.packageIndex(opts).neighbourhood(roots, opts)
+}
+
+func (r *Repo) moduleIndex(opts GraphOptions) graphIndex {
+	idx := graphIndex{nodes: make(map[string]GraphNode), forward: make(map[string][]string)}
+	for _, mod := range r.modules {
+		if mod.Type == DepTypeStdlib || opts.LocalOnly && mod.Type != DepTypeLocal {
+			continue
+		}
+		idx.nodes[mod.Path] = GraphNode{ID: mod.Path, Label: mod.Path, Module: mod.Path, Local: mod.Type == DepTypeLocal, License: mod.license.Expression()}
+		for _, dep := range mod.Dependencies {
+			if opts.LocalOnly && dep.Type != DepTypeLocal {
+				continue
+			}
+			idx.forward[mod.Path] = append(idx.forward[mod.Path], dep.Path)
+		}
+	}
+	return idx
+}
+
+func (r *Repo) packageIndex(opts GraphOptions) graphIndex {
+	idx := graphIndex{nodes: make(map[string]GraphNode), forward: make(map[string][]string)}
+	for _, m := range r.modules {
+		local := m.Type == DepTypeLocal
+		if !local && (opts.LocalOnly || !m.SourceLoaded()) {
+			continue
+		}
+		for _, p := range m.Packages {
+			id := packageID(p)
+			idx.nodes[id] = GraphNode{ID: id, Label: p.Name, Module: m.Path, Package: p.Name, Local: local, License: m.license.Expression()}
+			seen := make(map[string]bool)
+			for _, imp := range p.imports() {
+				dep, ok := r.importNode(m, imp)
+				if !ok || (opts.LocalOnly && !dep.Local) || seen[dep.ID] || dep.ID == id {
+					continue
+				}
+				seen[dep.ID] = true
+				if _, ok := idx.nodes[dep.ID]; !ok {
+					idx.nodes[dep.ID] = dep
+				}
+				idx.forward[id] = append(idx.forward[id], dep.ID)
+			}
+		}
+	}
+	return idx
+}
+
+// packageID identifies a local package in a package graph. Packages are known by name within
+// their module.
+func packageID(p *Package) string {
+	return p.Module.Path + "#" + p.Name
+}
+
+// imports returns the import paths of all files in the package, test files included.
+func (p *Package) imports() []string {
+	imports := make([]string, 0)
+	for _, f := range p.files {
+		if f.facts != nil {
+			imports = append(imports, f.facts.Imports...)
+		}
+	}
+	return imports
+}
+
+// importNode returns the graph node an import path of a module resolves to, false for the
+// standard library and local packages that don't exist. Modules are looked up first, like
+// AddImport does, as a local module can have a path without a dot, like mylib.
+func (r *Repo) importNode(from *Module, imp string) (GraphNode, bool) {
+	modPath, ok := r.FindModule(imp)
+	if !ok {
+		if from.stdlibImport(imp) {
+			return GraphNode{}, false
+		}
+		// a module we don't know of, probably required indirectly:
+		return GraphNode{ID: imp, Label: imp, Module: imp}, true
+	}
+	mod := r.modules[modPath]
+	if mod.Type == DepTypeStdlib {
+		return GraphNode{}, false
+	}
+	if mod.Type != DepTypeLocal && !mod.SourceLoaded() {
+		return GraphNode{ID: imp, Label: imp, Module: modPath, License: mod.license.Expression()}, true
+	}
+	p, ok := mod.importedPackage(imp)
+	if !ok {
+		return GraphNode{}, false
+	}
+	return GraphNode{ID: packageID(p), Label: p.Name, Module: mod.Path, Package: p.Name, Local: mod.Type == DepTypeLocal, License: mod.license.Expression()}, true
+}
+
+// isStdlib tells if an import path belongs to the standard library, whose paths have no dot
+// in the first element.
+func isStdlib(imp string) bool {
+	first, _, _ := strings.Cut(imp, "/")
+	return !strings.Contains(first, ".")
+}
+
+// stdlibImport tells if an import of the module is of the standard library: its path looks
+// like one, see isStdlib, and isn't in a module the module requires, like a module without a
+// dot in its path brought in with a replace directive.
+func (m *Module) stdlibImport(imp string) bool {
+	if !isStdlib(imp) {
+		return false
+	}
+	for p := imp; ; {
+		if _, ok := m.requires[p]; ok {
+			return false
+		}
+		i := strings.LastIndex(p, "/")
+		if i < 0 {
+			return true
+		}
+		p = p[:i]
+	}
+}
+
+// neighbourhood walks the graph from the roots, breadth first, up to the depth of the options.
+func (idx graphIndex) neighbourhood(roots []string, opts GraphOptions) *Graph {
+	reverse := make(map[string][]string)
+	for from, tos := range idx.forward {
+		for _, to := range tos {
+			reverse[to] = append(reverse[to], from)
+		}
+	}
+	nodes := make(map[string]int)
+	for _, root := range roots {
+		nodes[root] = 0
+	}
+	edges := make(map[GraphEdge]bool)
+	walk := func(next map[string][]string, sign int, edge func(a, b string) GraphEdge) {
+		frontier := roots
+		for d := 1; (opts.Depth <= 0 || d <= opts.Depth) && len(frontier) > 0; d++ {
+			var following []string
+			for _, id := range frontier {
+				for _, n := range next[id] {
+					edges[edge(id, n)] = true
+					if _, ok := nodes[n]; !ok {
+						nodes[n] = sign * d
+						following = append(following, n)
+					}
+				}
+			}
+			frontier = following
+		}
+	}
+	if opts.Direction == Dependencies || opts.Direction == Both {
+		walk(idx.forward, 1, func(a, b string) GraphEdge { return GraphEdge{From: a, To: b} })
+	}
+	if opts.Direction == Dependents || opts.Direction == Both {
+		walk(reverse, -1, func(a, b string) GraphEdge { return GraphEdge{From: b, To: a} })
+	}
+
+	g := &Graph{Nodes: make([]GraphNode, 0, len(nodes)), Edges: make([]GraphEdge, 0, len(edges))}
+	for id, d := range nodes {
+		n := idx.nodes[id]
+		n.Depth = d
+		g.Nodes = append(g.Nodes, n)
+	}
+	for e := range edges {
+		g.Edges = append(g.Edges, e)
+	}
+	if len(roots) == 1 {
+		g.Root = roots[0]
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		return g.Nodes[i].ID < g.Nodes[j].ID
+	})
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+	return g
+}
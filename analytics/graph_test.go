@@ -0,0 +1,198 @@
+package analytics
+
+import (
+	"fmt"
+	"testing"
+)
+
+// graphRepo sets up app -> lib -> base, where app also uses an external module, and tool -> lib.
+func graphRepo(t *testing.T) *Repo {
+	t.Helper()
+	r := &Repo{modules: make(map[string]*Module), snapshots: make(map[string]*Module)}
+	add := func(path string, files map[string]string, deps ...string) *Module {
+		m := writeModule(t, path, files)
+		m.Repo = r
+		r.modules[path] = m
+		if err := m.LoadSource(); err != nil {
+			t.Fatalf("LoadSource: %v", err)
+		}
+		for _, d := range deps {
+			m.Dependencies = append(m.Dependencies, r.modules[d])
+		}
+		return m
+	}
+	r.modules["example.com/ext"] = &Module{Path: "example.com/ext", Type: DepTypeExternal, Repo: r}
+	add("example.com/base", map[string]string{"base.go": "package base\n"})
+	add("example.com/lib", map[string]string{
+		"lib.go":      "package lib\n\nimport \"example.com/base\"\n",
+		"sub/sub.go":  "package sub\n\nimport \"example.com/lib\"\n",
+		"lib_test.go": "package lib\n\nimport \"testing\"\n",
+	}, "example.com/base")
+	add("example.com/app", map[string]string{
+		"main.go": "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/ext/client\"\n\t\"example.com/lib/sub\"\n)\n",
+	}, "example.com/lib", "example.com/ext")
+	add("example.com/tool", map[string]string{
+		"main.go": "package main\n\nimport \"example.com/lib\"\n",
+	}, "example.com/lib")
+	return r
+}
+
+func describe(g *Graph) (nodes, edges string) {
+	for _, n := range g.Nodes {
+		nodes += fmt.Sprintf("%s:%d ", n.ID, n.Depth)
+	}
+	for _, e := range g.Edges {
+		edges += fmt.Sprintf("%s>%s ", e.From, e.To)
+	}
+	return nodes, edges
+}
+
+func TestModuleGraph(t *testing.T) {
+	r := graphRepo(t)
+	tests := []struct {
+		name      string
+		root      string
+		opts      GraphOptions
+		wantNodes string
+		wantEdges string
+	}{
+		{
+			name:      "deps depth 1",
+			root:      "example.com/app",
+			opts:      GraphOptions{Depth: 1},
+			wantNodes: "example.com/app:0 example.com/ext:1 example.com/lib:1 ",
+			wantEdges: "example.com/app>example.com/ext example.com/app>example.com/lib ",
+		},
+		{
+			name:      "deps depth 2 local only",
+			root:      "example.com/lib",
+			opts:      GraphOptions{Depth: 2, LocalOnly: true},
+			wantNodes: "example.com/base:1 example.com/lib:0 ",
+			wantEdges: "example.com/lib>example.com/base ",
+		},
+		{
+			name:      "reverse",
+			root:      "example.com/lib",
+			opts:      GraphOptions{Depth: 3, Direction: Dependents},
+			wantNodes: "example.com/app:-1 example.com/lib:0 example.com/tool:-1 ",
+			wantEdges: "example.com/app>example.com/lib example.com/tool>example.com/lib ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := r.ModuleGraph(tt.root, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nodes, edges := describe(g)
+			if nodes != tt.wantNodes || edges != tt.wantEdges {
+				t.Errorf("got nodes %s, edges %s\nwant nodes %s, edges %s", nodes, edges, tt.wantNodes, tt.wantEdges)
+			}
+		})
+	}
+	if _, err := r.ModuleGraph("example.com/nope", GraphOptions{}); err == nil {
+		t.Errorf("unknown module: no error")
+	}
+}
+
+func TestPackageGraph(t *testing.T) {
+	r := graphRepo(t)
+	g, err := r.PackageGraph("example.com/lib", "sub", GraphOptions{Depth: 2, Direction: Both})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes, edges := describe(g)
+	// the standard library is left out, imports within a module are included:
+	wantNodes := "example.com/app#main:-1 example.com/base#base:2 example.com/lib#lib:1 example.com/lib#sub:0 "
+	wantEdges := "example.com/app#main>example.com/lib#sub example.com/lib#lib>example.com/base#base example.com/lib#sub>example.com/lib#lib "
+	if nodes != wantNodes || edges != wantEdges {
+		t.Errorf("got nodes %s, edges %s\nwant nodes %s, edges %s", nodes, edges, wantNodes, wantEdges)
+	}
+
+	g, err = r.PackageGraph("example.com/app", "main", GraphOptions{Depth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes, _ = describe(g)
+	if want := "example.com/app#main:0 example.com/ext/client:1 example.com/lib#sub:1 "; nodes != want {
+		t.Errorf("with external packages: got %s, want %s", nodes, want)
+	}
+	g, _ = r.PackageGraph("example.com/app", "main", GraphOptions{Depth: 1, LocalOnly: true})
+	if nodes, _ = describe(g); nodes != "example.com/app#main:0 example.com/lib#sub:1 " {
+		t.Errorf("local only: got %s", nodes)
+	}
+}
+
+func TestPackageGraphDotless(t *testing.T) {
+	r := &Repo{modules: make(map[string]*Module), snapshots: make(map[string]*Module)}
+	for path, files := range map[string]map[string]string{
+		"mylib": {"util/util.go": "package util\n"},
+		"myapp": {"main.go": "package main\n\nimport (\n\t\"fmt\"\n\n\t\"mylib/util\"\n)\n"},
+	} {
+		m := writeModule(t, path, files)
+		m.Repo = r
+		r.modules[path] = m
+		if err := m.LoadSource(); err != nil {
+			t.Fatalf("LoadSource: %v", err)
+		}
+	}
+	g, err := r.PackageGraph("myapp", "main", GraphOptions{Depth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a local module without a dot in its path isn't the standard library:
+	if nodes, edges := describe(g); nodes != "myapp#main:0 mylib#util:1 " || edges != "myapp#main>mylib#util " {
+		t.Errorf("got nodes %s, edges %s", nodes, edges)
+	}
+}
+
+func TestLocalGraph(t *testing.T) {
+	r := graphRepo(t)
+	g := r.LocalModuleGraph("example.com/", GraphOptions{LocalOnly: true})
+	nodes, edges := describe(g)
+	wantNodes := "example.com/app:0 example.com/base:0 example.com/lib:0 example.com/tool:0 "
+	wantEdges := "example.com/app>example.com/lib example.com/lib>example.com/base example.com/tool>example.com/lib "
+	if g.Root != "" || nodes != wantNodes || edges != wantEdges {
+		t.Errorf("got root %q, nodes %s, edges %s", g.Root, nodes, edges)
+	}
+	g = r.LocalModuleGraph("example.com/app", GraphOptions{Depth: 1})
+	if nodes, _ = describe(g); g.Root != "example.com/app" || nodes != "example.com/app:0 example.com/ext:1 example.com/lib:1 " {
+		t.Errorf("got root %q, nodes %s", g.Root, nodes)
+	}
+	g = r.LocalPackageGraph("example.com/lib", GraphOptions{})
+	nodes, edges = describe(g)
+	wantNodes = "example.com/base#base:1 example.com/lib#lib:0 example.com/lib#sub:0 "
+	wantEdges = "example.com/lib#lib>example.com/base#base example.com/lib#sub>example.com/lib#lib "
+	if nodes != wantNodes || edges != wantEdges {
+		t.Errorf("package graph: got nodes %s, edges %s", nodes, edges)
+	}
+}
+
+func TestPackageGraphReplaced(t *testing.T) {
+	r := &Repo{modules: make(map[string]*Module), snapshots: make(map[string]*Module)}
+	m := writeModule(t, "example.com/app", map[string]string{
+		"main.go": "package main\n\nimport (\n\t\"fmt\"\n\n\t\"mycorp/lib/util\"\n)\n",
+	})
+	m.Repo = r
+	r.modules[m.Path] = m
+	// mycorp/lib is replaced with a directory outside the repo:
+	m.requires = map[string]string{"mycorp/lib": "v0.0.0"}
+	if err := m.LoadSource(); err != nil {
+		t.Fatalf("LoadSource: %v", err)
+	}
+	m.resolveImports()
+	std := r.stdlibModule()
+	if _, ok := std.packageDirs["mycorp/lib/util"]; ok {
+		t.Error("mycorp/lib/util was taken for the standard library")
+	}
+	if _, ok := std.packageDirs["fmt"]; !ok {
+		t.Error("fmt should be in the standard library")
+	}
+	g, err := r.PackageGraph(m.Path, "main", GraphOptions{Depth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nodes, edges := describe(g); nodes != "example.com/app#main:0 mycorp/lib/util:1 " || edges != "example.com/app#main>mycorp/lib/util " {
+		t.Errorf("got nodes %s, edges %s", nodes, edges)
+	}
+}
@@ -0,0 +1,65 @@
+package analytics
+
+import (
+	"github.com/perbu/gogrok/history"
+	"log/slog"
+	"time"
+)
+
+// loadHistory attaches the git history since the given time to the local modules and their files.
+// Modules that aren't git checkouts are skipped. On a rebuild the history of unchanged modules
+// is carried over.
+func (r *Repo) loadHistory(since time.Time) {
+	for _, mod := range r.modules {
+		if mod.Type != DepTypeLocal {
+			continue
+		}
+		files, err := r.moduleHistory(mod, since)
+		if err != nil {
+			slog.Warn("no git history", "module", mod.Path, "error", err)
+			continue
+		}
+		mod.fileHistory = files
+		mod.history = history.Aggregate(files)
+		for _, pkg := range mod.Packages {
+			for _, f := range pkg.files {
+				f.history = files[f.Path]
+			}
+		}
+	}
+}
+
+// moduleHistory returns the history of a module by file, carried over from the previous
+// analysis if the checkout didn't change.
+func (r *Repo) moduleHistory(mod *Module, since time.Time) (map[string]*history.Stats, error) {
+	if old, ok := r.unchanged(mod); ok && old.fileHistory != nil {
+		return old.fileHistory, nil
+	}
+	return history.Log(mod.Location, since)
+}
+
+// History returns the git history of the file within the configured window.
+func (f *File) History() *history.Stats {
+	if f.history == nil {
+		return history.NewStats()
+	}
+	return f.history
+}
+
+// History returns the aggregated git history of the files in the package, tests included.
+func (p *Package) History() *history.Stats {
+	total := history.NewStats()
+	for _, f := range p.files {
+		total.Merge(f.history)
+	}
+	return total
+}
+
+// History returns the git history of the module. It covers every file in the module,
+// not just the Go source.
+func (m *Module) History() *history.Stats {
+	if m.history == nil {
+		return history.NewStats()
+	}
+	return m.history
+}
@@ -0,0 +1,53 @@
+package analytics
+
+import "sort"
+
+// Hotspot is a file that is both complex and changed often. These are the files where
+// refactoring pays off the most.
+type Hotspot struct {
+	File       *File
+	Complexity float32
+	Commits    int     // commits touching the file within the history window
+	Churn      int     // lines added and removed within the history window
+	Score      float32 // complexity times change frequency, both relative to the largest in the repo, 0-1
+}
+
+// Hotspots ranks the production files of all local modules by complexity times change
+// frequency. Files without any commits in the history window are left out.
+func (r *Repo) Hotspots() []Hotspot {
+	hotspots := make([]Hotspot, 0)
+	maxComplexity, maxCommits := float32(0), 0
+	for _, mod := range r.modules {
+		if mod.Type != DepTypeLocal {
+			continue
+		}
+		for _, pkg := range mod.Packages {
+			for _, f := range pkg.countedFiles() {
+				commits := f.History().Commits()
+				if commits == 0 || f.Type == GeneratedGo {
+					continue
+				}
+				h := Hotspot{
+					File:       f,
+					Complexity: f.CalculateComplexity(),
+					Commits:    commits,
+					Churn:      f.History().Churn(),
+				}
+				maxComplexity = max(maxComplexity, h.Complexity)
+				maxCommits = max(maxCommits, h.Commits)
+				hotspots = append(hotspots, h)
+			}
+		}
+	}
+	for i := range hotspots {
+		hotspots[i].Score = hotspots[i].Complexity / maxComplexity *
+			float32(hotspots[i].Commits) / float32(maxCommits)
+	}
+	sort.Slice(hotspots, func(i, j int) bool {
+		if hotspots[i].Score != hotspots[j].Score {
+			return hotspots[i].Score > hotspots[j].Score
+		}
+		return hotspots[i].File.Path < hotspots[j].File.Path
+	})
+	return hotspots
+}
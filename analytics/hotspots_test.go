@@ -0,0 +1,49 @@
+package analytics
+
+import (
+	"fmt"
+	"github.com/perbu/gogrok/history"
+	"testing"
+	"time"
+)
+
+func TestHotspots(t *testing.T) {
+	m := writeModule(t, "example.com/hot", map[string]string{
+		"simple.go":  "package hot\n\nfunc A() {}\n",
+		"complex.go": "package hot\n\nfunc B(a, b bool) {\n\tif a && b {\n\t}\n\tfor {\n\t}\n}\n",
+		"quiet.go":   "package hot\n\nfunc C(a bool) {\n\tif a {\n\t}\n}\n",
+	})
+	if err := m.LoadSource(); err != nil {
+		t.Fatalf("LoadSource: %v", err)
+	}
+	changes := map[string]int{"simple.go": 4, "complex.go": 2}
+	files := make(map[string]*history.Stats)
+	for name, commits := range changes {
+		files[name] = fakeHistory(t, commits)
+	}
+	for _, pkg := range m.Packages {
+		for _, f := range pkg.files {
+			f.history = files[f.Path]
+		}
+	}
+	hotspots := m.Repo.Hotspots()
+	if len(hotspots) != 2 {
+		t.Fatalf("Hotspots() = %d, want 2 (quiet.go has no commits)", len(hotspots))
+	}
+	if hotspots[0].File.Name != "complex.go" {
+		t.Errorf("top hotspot = %s, want complex.go", hotspots[0].File.Name)
+	}
+	if hotspots[0].Score != 0.5 {
+		t.Errorf("top score = %v, want 0.5 (max complexity, half the commits)", hotspots[0].Score)
+	}
+}
+
+// fakeHistory builds a file history with the given number of one-line commits.
+func fakeHistory(t *testing.T, commits int) *history.Stats {
+	t.Helper()
+	st := history.NewStats()
+	for i := 0; i < commits; i++ {
+		st.Add(fmt.Sprintf("%040d", i), "dev@example.com", time.Now(), 1, 0)
+	}
+	return st
+}
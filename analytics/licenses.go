@@ -0,0 +1,146 @@
+package analytics
+
+import (
+	"errors"
+	"github.com/perbu/gogrok/license"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// License returns the license files found for the module and the licenses recognized in them.
+// External modules only have licenses when the module cache has the version the local modules
+// select.
+func (m *Module) License() license.Info {
+	return m.license
+}
+
+// LicenseVerdict evaluates the license of the module against the configured policy.
+func (m *Module) LicenseVerdict() license.Verdict {
+	return m.Repo.config.LicensePolicy.Evaluate(m.license)
+}
+
+// SelectedVersion returns the version of an external module the build of the local modules
+// would use, the highest version any of them requires. It is empty if none require it.
+func (r *Repo) SelectedVersion(m *Module) string {
+	selected := ""
+	for _, mod := range r.modules {
+		if mod.Type != DepTypeLocal {
+			continue
+		}
+		if v := mod.requires[m.Path]; v != "" && semver.Compare(v, selected) > 0 {
+			selected = v
+		}
+	}
+	return selected
+}
+
+// loadLicenses detects the licenses of the local checkouts and, with a module cache
+// configured, of the external modules extracted in it.
+func (r *Repo) loadLicenses() {
+	for _, mod := range r.modules {
+		var fsys fs.FS
+		switch mod.Type {
+		case DepTypeLocal:
+			fsys = os.DirFS(mod.Location)
+		case DepTypeExternal:
+			dir, ok := r.modCacheDir(mod)
+			if !ok {
+				continue
+			}
+			fsys = os.DirFS(dir)
+		default:
+			continue
+		}
+		info, err := license.Detect(fsys)
+		if err != nil {
+			slog.Warn("license detection failed", "module", mod.Path, "error", err)
+			continue
+		}
+		mod.license = info
+	}
+}
+
+// modCacheDir returns the directory the selected version of an external module is extracted to
+// in the module cache, if it is there.
+func (r *Repo) modCacheDir(m *Module) (string, bool) {
+	if r.config.ModCache == "" {
+		return "", false
+	}
+	version := r.SelectedVersion(m)
+	if version == "" {
+		return "", false
+	}
+	escPath, err := module.EscapePath(m.Path)
+	if err != nil {
+		return "", false
+	}
+	escVersion, err := module.EscapeVersion(version)
+	if err != nil {
+		return "", false
+	}
+	dir := filepath.Join(r.config.ModCache, escPath+"@"+escVersion)
+	if _, err := os.Stat(dir); err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			slog.Warn("unreadable module cache", "module", m.Path, "error", err)
+		}
+		return "", false
+	}
+	return dir, true
+}
+
+// LicenseGroup is the modules sharing a license, or the lack of one.
+type LicenseGroup struct {
+	License string // SPDX license expression, empty for the modules without recognized licenses
+	Verdict license.Verdict
+	Modules []*Module // sorted by path
+}
+
+// Licenses groups the local and external modules by license, the groups the policy objects to
+// first.
+func (r *Repo) Licenses() []LicenseGroup {
+	byLicense := make(map[string]*LicenseGroup)
+	for _, mod := range r.modules {
+		if mod.Type == DepTypeStdlib {
+			continue
+		}
+		expr := mod.license.Expression()
+		g, ok := byLicense[expr]
+		if !ok {
+			g = &LicenseGroup{License: expr, Verdict: mod.LicenseVerdict()}
+			byLicense[expr] = g
+		}
+		g.Modules = append(g.Modules, mod)
+	}
+	groups := make([]LicenseGroup, 0, len(byLicense))
+	for _, g := range byLicense {
+		sort.Slice(g.Modules, func(i, j int) bool {
+			return g.Modules[i].Path < g.Modules[j].Path
+		})
+		groups = append(groups, *g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Verdict != groups[j].Verdict {
+			return verdictRank(groups[i].Verdict) < verdictRank(groups[j].Verdict)
+		}
+		return groups[i].License < groups[j].License
+	})
+	return groups
+}
+
+// verdictRank orders verdicts from the most to the least pressing.
+func verdictRank(v license.Verdict) int {
+	switch v {
+	case license.Denied:
+		return 0
+	case license.Unlisted:
+		return 1
+	case license.Unknown:
+		return 2
+	}
+	return 3
+}
@@ -0,0 +1,59 @@
+package analytics
+
+import (
+	"github.com/perbu/gogrok/license"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLicenses(t *testing.T) {
+	modCache := t.TempDir()
+	// the module cache escapes upper case letters in paths:
+	dir := filepath.Join(modCache, "github.com", "!some!one", "lib@v1.2.0")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "LICENSE"), []byte("GNU AFFERO GENERAL PUBLIC LICENSE\nVersion 3, 19 November 2007\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &Repo{
+		modules:   make(map[string]*Module),
+		snapshots: make(map[string]*Module),
+		config: Config{
+			ModCache:      modCache,
+			LicensePolicy: &license.Policy{Deny: []string{"AGPL-3.0"}},
+		},
+	}
+	svc := sumModule(t, r, "example.com/svc", "module example.com/svc\n\ngo 1.22\n\nrequire (\n\tgithub.com/SomeOne/lib v1.2.0\n\texample.com/gone v0.1.0\n)\n", "")
+	if err := os.WriteFile(filepath.Join(svc.Location, "LICENSE.md"), []byte("// SPDX-License-Identifier: MIT\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := sumModule(t, r, "example.com/old", "module example.com/old\n\ngo 1.22\n\nrequire github.com/SomeOne/lib v1.1.0\n", "")
+	lib := &Module{Path: "github.com/SomeOne/lib", Type: DepTypeExternal, Repo: r}
+	gone := &Module{Path: "example.com/gone", Type: DepTypeExternal, Repo: r}
+	r.modules[lib.Path], r.modules[gone.Path] = lib, gone
+
+	if v := r.SelectedVersion(lib); v != "v1.2.0" {
+		t.Errorf("selected version: %s", v)
+	}
+	r.loadLicenses()
+	if svc.License().Expression() != "MIT" || svc.LicenseVerdict() != license.Allowed {
+		t.Errorf("local module: %+v", svc.License())
+	}
+	if lib.License().Expression() != "AGPL-3.0-only" || lib.LicenseVerdict() != license.Denied {
+		t.Errorf("external module: %+v", lib.License())
+	}
+	if gone.License().Found() || gone.LicenseVerdict() != license.Unknown {
+		t.Errorf("module missing from the cache: %+v", gone.License())
+	}
+
+	groups := r.Licenses()
+	if len(groups) != 3 || groups[0].License != "AGPL-3.0-only" || groups[1].License != "" || groups[2].License != "MIT" {
+		t.Fatalf("groups: %+v", groups)
+	}
+	if unknown := groups[1].Modules; len(unknown) != 2 || unknown[0] != gone || unknown[1] != old {
+		t.Errorf("modules without a license: %+v", unknown)
+	}
+}
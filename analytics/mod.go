@@ -9,6 +9,7 @@ type DepType int
 const (
 	DepTypeLocal DepType = iota + 1
 	DepTypeExternal
+	DepTypeStdlib // the standard library, a single pseudo-module, see StdlibPath
 )
 
 func (m *Module) Lines() int {
@@ -2,68 +2,130 @@ package analytics
 
 import (
 	"fmt"
-	"go/ast"
-	"go/parser"
-	"go/token"
+	"io/fs"
+	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 )
 
-// LoadSource loads the source code for a local module.
+// LoadSource loads the source code for a local module from the working tree.
 func (m *Module) LoadSource() error {
 	if m.Location == "" {
 		return fmt.Errorf("module (%s) location is empty", m.Path)
 	}
-	err := filepath.Walk(m.Location, func(path string, info os.FileInfo, err error) error {
-		if info == nil {
-			return nil
-		}
+	return m.loadSource(os.DirFS(m.Location))
+}
+
+// loadSource loads the source code of the module from fsys, which is rooted at the module.
+// Files are only parsed if their facts aren't cached. Like the go command, it skips testdata
+// and directories whose names start with . or _. Imports are resolved separately, once every
+// module is loaded, see resolveImports.
+func (m *Module) loadSource(fsys fs.FS) error {
+	m.fsys = fsys
+	m.packageDirs = make(map[string]*Package)
+	m.dockerfiles = make(map[string]string)
+	parsed, cached := 0, 0
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
-		if info.IsDir() || filepath.Ext(path) != ".go" {
+		if d.IsDir() {
+			if base := d.Name(); name != "." && (base == "testdata" || strings.HasPrefix(base, ".") || strings.HasPrefix(base, "_")) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if m.Type == DepTypeLocal && isDockerfile(d.Name()) {
+			content, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				slog.Warn("reading Dockerfile", "module", m.Path, "file", name, "error", err)
+				return nil
+			}
+			m.dockerfiles[name] = string(content)
+			return nil
+		}
+		if path.Ext(name) != ".go" {
 			return nil
 		}
-		fset := token.NewFileSet()
-		astFile, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		facts, fromCache, err := m.loadFacts(fsys, name, d)
 		if err != nil {
 			fmt.Println(err)
 			return err
 		}
-		// Ensure we know about the package
-		p, ok := m.GetPackage(astFile.Name.Name)
+		if fromCache {
+			cached++
+		} else {
+			parsed++
+		}
+		// Ensure we know about the package.
+		p, ok := m.GetPackage(facts.Package)
 		if !ok {
-			rpath, err := filepath.Rel(m.Location, path)
-			if err != nil {
-				fmt.Println(err)
-				return fmt.Errorf("filepath.Rel: %w", err)
-			}
 			p = &Package{
-				Name:                astFile.Name.Name,
-				Location:            rpath,
+				Name:                facts.Package,
+				Location:            name,
 				Module:              m,
 				files:               make([]*File, 0),
 				ReverseDependencies: make([]*Package, 0),
 			}
 			m.AddPackage(p)
 		}
-		// Ensure we know about the astFile in the package.
-		// Every astFile is new, so we don't need to check for existence.
-		f := p.AddFile(path, astFile)
-		ast.Inspect(astFile, func(n ast.Node) bool {
-			// Find Import Specs
-			imp, ok := n.(*ast.ImportSpec)
-			if ok {
-				// strip quotes
-				imp.Path.Value = imp.Path.Value[1 : len(imp.Path.Value)-1]
-				f.AddImport(imp.Path.Value)
-			}
-			return true
-		})
+		m.packageDirs[path.Dir(name)] = p
+		// Ensure we know about the file in the package.
+		// Every file is new, so we don't need to check for existence.
+		p.addFile(name, facts)
 		return nil
 	})
 	if err != nil {
-		return fmt.Errorf("filepath.Walk: %w", err)
+		return fmt.Errorf("fs.WalkDir: %w", err)
+	}
+	if err := m.Repo.cache.flush(); err != nil {
+		slog.Warn("fact cache", "module", m.Path, "error", err)
 	}
+	slog.Debug("loaded source", "module", m.Path, "ref", m.Ref, "parsed", parsed, "cached", cached)
 	return nil
 }
+
+// resolveImports links the files of the module to the packages they import, in local modules
+// and in external modules with loaded source.
+func (m *Module) resolveImports() {
+	for _, pkg := range m.Packages {
+		for _, f := range pkg.files {
+			f.Imports = make([]*Package, 0, len(f.facts.Imports))
+			for _, imp := range f.facts.Imports {
+				f.AddImport(imp)
+			}
+		}
+	}
+}
+
+// loadFacts returns the facts of a file, from the cache if it's there. Files in the working
+// tree are first looked up by modification time and size, so unchanged files aren't read.
+func (m *Module) loadFacts(fsys fs.FS, name string, d fs.DirEntry) (*fileFacts, bool, error) {
+	cache := m.Repo.cache
+	var st *stamp
+	if cache != nil && m.Ref == "" {
+		if info, err := d.Info(); err == nil {
+			st = &stamp{Path: filepath.Join(m.Location, name), ModTime: info.ModTime().UnixNano(), Size: info.Size()}
+			if facts, ok := cache.byStamp(*st); ok {
+				return facts, true, nil
+			}
+		}
+	}
+	src, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, false, fmt.Errorf("fs.ReadFile: %w", err)
+	}
+	key := contentKey(name, src)
+	if facts, ok := cache.get(key); ok {
+		cache.put(key, nil, st)
+		return facts, true, nil
+	}
+	facts, err := extractFacts(filepath.Join(m.Location, name), src)
+	if err != nil {
+		return nil, false, err
+	}
+	cache.put(key, facts, st)
+	return facts, false, nil
+}
@@ -7,3 +7,32 @@ func (m *Module) IsLatestVersion(version string) bool {
 	latest := m.versions[len(m.versions)-1]
 	return version == latest
 }
+
+// RequiredVersion returns the version of dep required by the go.mod of the module, empty if
+// the module doesn't require it.
+func (m *Module) RequiredVersion(dep *Module) string {
+	return m.requires[dep.Path]
+}
+
+// OutdatedDependency is a dependency required at an older version than the latest one known.
+type OutdatedDependency struct {
+	Module     *Module // the module requiring the dependency
+	Dependency *Module
+	Required   string
+	Latest     string
+}
+
+// OutdatedDependencies returns the dependencies of the module with a newer version available.
+func (m *Module) OutdatedDependencies() []OutdatedDependency {
+	outdated := make([]OutdatedDependency, 0)
+	for _, dep := range m.Dependencies {
+		required, latest := m.RequiredVersion(dep), dep.Latest()
+		if required == "" || latest == "" {
+			continue
+		}
+		if semver.Compare(required, latest) < 0 {
+			outdated = append(outdated, OutdatedDependency{Module: m, Dependency: dep, Required: required, Latest: latest})
+		}
+	}
+	return outdated
+}
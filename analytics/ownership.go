@@ -0,0 +1,125 @@
+package analytics
+
+import (
+	"github.com/perbu/gogrok/history"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// inactiveAfter is how long an author can go without committing before their knowledge
+// of the code is considered at risk.
+const inactiveAfter = 365 * 24 * time.Hour
+
+// loadOwnership blames the production files of the local modules and records when each
+// author last committed. Modules that aren't git checkouts are skipped. On a rebuild the
+// ownership of unchanged modules is carried over.
+func (r *Repo) loadOwnership() {
+	for _, mod := range r.modules {
+		if mod.Type != DepTypeLocal {
+			continue
+		}
+		if old, ok := r.unchanged(mod); ok && old.fileOwnership != nil {
+			mod.attachOwnership(old.fileOwnership)
+			mod.lastCommits = old.lastCommits
+			continue
+		}
+		paths := make([]string, 0)
+		for _, pkg := range mod.Packages {
+			for _, f := range pkg.countedFiles() {
+				paths = append(paths, f.Path)
+			}
+		}
+		owners, err := history.Blame(mod.Location, paths)
+		if err != nil {
+			slog.Warn("git blame failed", "module", mod.Path, "error", err)
+			continue
+		}
+		mod.attachOwnership(owners)
+		mod.lastCommits, err = history.LastCommits(mod.Location)
+		if err != nil {
+			slog.Warn("git log failed", "module", mod.Path, "error", err)
+		}
+	}
+}
+
+func (m *Module) attachOwnership(owners map[string]history.Ownership) {
+	m.fileOwnership = owners
+	for _, pkg := range m.Packages {
+		for _, f := range pkg.files {
+			f.ownership = owners[f.Path]
+		}
+	}
+}
+
+// Ownership returns the lines of the file per author.
+func (f *File) Ownership() history.Ownership {
+	own := make(history.Ownership)
+	own.Merge(f.ownership)
+	return own
+}
+
+// Ownership returns the lines per author for the production files of the package.
+func (p *Package) Ownership() history.Ownership {
+	own := make(history.Ownership)
+	for _, f := range p.countedFiles() {
+		own.Merge(f.ownership)
+	}
+	return own
+}
+
+// Ownership returns the lines per author for the module.
+func (m *Module) Ownership() history.Ownership {
+	own := make(history.Ownership)
+	for _, pkg := range m.Packages {
+		own.Merge(pkg.Ownership())
+	}
+	return own
+}
+
+// LastCommitBy returns when the author last committed to the module, zero if unknown.
+func (m *Module) LastCommitBy(email string) time.Time {
+	return m.lastCommits[email]
+}
+
+// KnowledgeRisk is a module whose knowledge rests with a single person, or with people
+// who haven't committed for a long time.
+type KnowledgeRisk struct {
+	Module         *Module
+	BusFactor      int
+	MajorityOwners []history.Owner // the authors owning more than half of the lines
+	Inactive       bool            // none of the majority owners committed within the last year
+}
+
+// KnowledgeRisks returns the local modules with a bus factor of one or inactive owners,
+// sorted by module path.
+func (r *Repo) KnowledgeRisks() []KnowledgeRisk {
+	risks := make([]KnowledgeRisk, 0)
+	for _, mod := range r.modules {
+		if mod.Type != DepTypeLocal {
+			continue
+		}
+		own := mod.Ownership()
+		if own.Total() == 0 {
+			continue
+		}
+		risk := KnowledgeRisk{
+			Module:         mod,
+			BusFactor:      own.BusFactor(),
+			MajorityOwners: own.MajorityOwners(),
+			Inactive:       true,
+		}
+		for _, owner := range risk.MajorityOwners {
+			if time.Since(mod.LastCommitBy(owner.Email)) < inactiveAfter {
+				risk.Inactive = false
+			}
+		}
+		if risk.BusFactor == 1 || risk.Inactive {
+			risks = append(risks, risk)
+		}
+	}
+	sort.Slice(risks, func(i, j int) bool {
+		return risks[i].Module.Path < risks[j].Module.Path
+	})
+	return risks
+}
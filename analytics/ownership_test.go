@@ -0,0 +1,40 @@
+package analytics
+
+import (
+	"github.com/perbu/gogrok/history"
+	"testing"
+	"time"
+)
+
+func TestKnowledgeRisks(t *testing.T) {
+	solo := writeModule(t, "example.com/solo", map[string]string{"a.go": "package a\n"})
+	shared := writeModule(t, "example.com/shared", map[string]string{"b.go": "package b\n"})
+	for _, m := range []*Module{solo, shared} {
+		if err := m.LoadSource(); err != nil {
+			t.Fatalf("LoadSource: %v", err)
+		}
+	}
+	// put both modules into the same repo:
+	r := solo.Repo
+	shared.Repo = r
+	r.modules[shared.Path] = shared
+
+	solo.Packages[0].files[0].ownership = history.Ownership{"alice@example.com": 10}
+	solo.lastCommits = map[string]time.Time{"alice@example.com": time.Now()}
+	shared.Packages[0].files[0].ownership = history.Ownership{"bob@example.com": 5, "carol@example.com": 5}
+	shared.lastCommits = map[string]time.Time{
+		"bob@example.com":   time.Now().Add(-2 * inactiveAfter),
+		"carol@example.com": time.Now().Add(-2 * inactiveAfter),
+	}
+
+	risks := r.KnowledgeRisks()
+	if len(risks) != 2 {
+		t.Fatalf("KnowledgeRisks() = %d risks, want 2", len(risks))
+	}
+	if risks[0].Module != shared || risks[0].BusFactor != 2 || !risks[0].Inactive {
+		t.Errorf("shared: bus factor %d, inactive %v; want 2, true", risks[0].BusFactor, risks[0].Inactive)
+	}
+	if risks[1].Module != solo || risks[1].BusFactor != 1 || risks[1].Inactive {
+		t.Errorf("solo: bus factor %d, inactive %v; want 1, false", risks[1].BusFactor, risks[1].Inactive)
+	}
+}
@@ -2,16 +2,29 @@ package analytics
 
 import "log/slog"
 
+// Lines returns the lines of code in the package. Test files are excluded unless the repo
+// is configured to include them, see TestLines for those.
 func (p *Package) Lines() int {
 	lines := 0
-	for _, file := range p.files {
-		lines += len(file.source)
+	for _, file := range p.countedFiles() {
+		lines += file.Lines()
 	}
 	return lines
 }
 
 func (p *Package) Files() int {
-	return len(p.files)
+	return len(p.countedFiles())
+}
+
+// countedFiles returns the files that make up the production metrics.
+func (p *Package) countedFiles() []*File {
+	files := make([]*File, 0, len(p.files))
+	for _, file := range p.files {
+		if file.counted() {
+			files = append(files, file)
+		}
+	}
+	return files
 }
 
 func (p *Package) GetFiles() []*File {
@@ -31,20 +44,27 @@ func (p *Package) GetFile(name string) (*File, bool) {
 func (p *Package) Generated() float32 {
 	generated := 0
 	total := 0
-	for _, file := range p.files {
+	for _, file := range p.countedFiles() {
 		if file.Type == GeneratedGo {
 			generated += file.Lines()
 		}
 		total += file.Lines()
 	}
+	if total == 0 {
+		return 0
+	}
 	return float32(generated) / float32(total)
 }
 
 func (p *Package) CalculateComplexity() float32 {
+	files := p.countedFiles()
+	if len(files) == 0 {
+		return 0
+	}
 	complexity := float32(0)
-	for _, file := range p.files {
+	for _, file := range files {
 		complexity += file.CalculateComplexity()
 	}
-	slog.Info("complexity", "package", p.Name, "complexity", complexity, "files", len(p.files))
-	return complexity / float32(len(p.files))
+	slog.Info("complexity", "package", p.Name, "complexity", complexity, "files", len(files))
+	return complexity / float32(len(files))
 }
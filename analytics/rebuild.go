@@ -0,0 +1,53 @@
+package analytics
+
+import (
+	"fmt"
+	"github.com/perbu/gogrok/modver"
+	"log/slog"
+	"path"
+	"time"
+)
+
+// Rebuild analyzes the repo again after the checkouts in the changed directories (names of
+// directories in the base path) were modified. Only files missing from the fact cache are
+// parsed, and the git history and ownership of unchanged modules are carried over. The
+// receiver is left untouched and can keep serving requests until the new repo replaces it.
+func (r *Repo) Rebuild(changed []string) (*Repo, error) {
+	start := time.Now()
+	next := &Repo{
+		modules:    make(map[string]*Module),
+		basePath:   r.basePath,
+		modTracker: modver.New(),
+		config:     r.config,
+		snapshots:  make(map[string]*Module),
+		cache:      r.cache,
+		previous:   r,
+		changed:    make(map[string]bool, len(changed)),
+	}
+	for _, dir := range changed {
+		next.changed[path.Join(r.basePath, dir)] = true
+	}
+	err := next.Parse()
+	// let go of the previous analysis:
+	next.previous, next.changed = nil, nil
+	if err != nil {
+		// Parse closes the version cache only when it succeeds:
+		_ = next.modTracker.Close()
+		return nil, fmt.Errorf("r.Parse: %w", err)
+	}
+	slog.Info("rebuilt analysis", "changed", changed, "duration", time.Since(start))
+	return next, nil
+}
+
+// unchanged returns the module from the previous analysis during a rebuild, if its checkout
+// didn't change.
+func (r *Repo) unchanged(mod *Module) (*Module, bool) {
+	if r.previous == nil || r.changed[mod.Location] {
+		return nil, false
+	}
+	old, ok := r.previous.GetModule(mod.Path)
+	if !ok || old.Location != mod.Location {
+		return nil, false
+	}
+	return old, true
+}
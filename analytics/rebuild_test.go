@@ -0,0 +1,50 @@
+package analytics
+
+import (
+	"github.com/perbu/gogrok/history"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRebuildCarriesOverHistory(t *testing.T) {
+	files := map[string]string{"a.go": "package a\n"}
+	old := writeModule(t, "example.com/a", files)
+	if err := old.LoadSource(); err != nil {
+		t.Fatalf("LoadSource: %v", err)
+	}
+	st := history.NewStats()
+	st.Add("abc", "alice@example.com", time.Now(), 1, 0)
+	old.fileHistory = map[string]*history.Stats{"a.go": st}
+
+	for _, tt := range []struct {
+		name        string
+		changed     bool
+		wantCommits int
+	}{
+		{name: "unchanged", wantCommits: 1},
+		// the module isn't a git checkout, so a changed module ends up without history:
+		{name: "changed", changed: true, wantCommits: 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Repo{
+				modules:  make(map[string]*Module),
+				basePath: filepath.Dir(old.Location),
+				previous: old.Repo,
+				changed:  map[string]bool{old.Location: tt.changed},
+			}
+			mod := &Module{Path: old.Path, Location: old.Location, Type: DepTypeLocal, Repo: r}
+			r.modules[mod.Path] = mod
+			if err := mod.LoadSource(); err != nil {
+				t.Fatalf("LoadSource: %v", err)
+			}
+			r.loadHistory(time.Now().Add(-time.Hour))
+			if got := mod.History().Commits(); got != tt.wantCommits {
+				t.Errorf("module commits = %d, want %d", got, tt.wantCommits)
+			}
+			if got := mod.Packages[0].files[0].History().Commits(); got != tt.wantCommits {
+				t.Errorf("file commits = %d, want %d", got, tt.wantCommits)
+			}
+		})
+	}
+}
@@ -14,15 +14,29 @@ import (
 	"time"
 )
 
-func New(path string) (*Repo, error) {
+func New(path string, config Config) (*Repo, error) {
 	r := &Repo{
 		modules:    make(map[string]*Module),
 		basePath:   path,
 		modTracker: modver.New(),
+		config:     config,
+		snapshots:  make(map[string]*Module),
+	}
+	if config.CacheFile != "" {
+		cache, err := openFactCache(config.CacheFile)
+		if err != nil {
+			return nil, fmt.Errorf("openFactCache: %w", err)
+		}
+		r.cache = cache
 	}
 	return r, nil
 }
 
+// Close releases the fact cache.
+func (r *Repo) Close() error {
+	return r.cache.close()
+}
+
 func (r *Repo) Parse() error {
 	start := time.Now()
 	repoDirs, err := os.ReadDir(r.basePath)
@@ -49,15 +63,41 @@ func (r *Repo) Parse() error {
 		if !ok {
 			return fmt.Errorf("r.GetModule(%s): not found", name)
 		}
-		// Load source code for local modules
-		if mod.Type == DepTypeLocal {
+		// Load source code for local modules, and external modules if configured
+		switch {
+		case mod.Type == DepTypeLocal:
 			err := mod.LoadSource()
 			if err != nil {
 				return fmt.Errorf("mod.LoadSource: %w", err)
 			}
+		case r.config.LoadExternal:
+			r.loadExternalSource(mod)
 		}
 	}
+	for _, mod := range r.modules {
+		mod.resolveImports()
+	}
 	slog.Info("parsed source code", "duration", time.Since(start))
+	r.loadCodeowners()
+	r.loadLicenses()
+	if r.config.CoverageDir != "" {
+		start = time.Now()
+		err = r.LoadCoverage(r.config.CoverageDir)
+		if err != nil {
+			return fmt.Errorf("r.LoadCoverage: %w", err)
+		}
+		slog.Info("loaded coverage", "duration", time.Since(start))
+	}
+	if r.config.HistoryWindow > 0 {
+		start = time.Now()
+		r.loadHistory(start.Add(-r.config.HistoryWindow))
+		slog.Info("walked git history", "duration", time.Since(start))
+	}
+	if r.config.Ownership {
+		start = time.Now()
+		r.loadOwnership()
+		slog.Info("computed code ownership", "duration", time.Since(start))
+	}
 	// Populate reverse dependencies, both packages and modules.
 	start = time.Now()
 	r.reverseDeps()
@@ -157,10 +197,14 @@ func (r *Repo) ParseMod(modulePath string) error {
 			LatestVersion:             latestVersion,
 		}
 	}
+	if m.requires == nil {
+		m.requires = make(map[string]string, len(file.Require))
+	}
 	m.Type = DepTypeLocal
 	m.AddVersion(latestVersion)
 	m.Location = modulePath
 	for _, require := range file.Require {
+		m.requires[require.Mod.Path] = require.Mod.Version
 		ref, ok := r.modules[require.Mod.Path]
 		switch ok {
 		case true:
@@ -179,6 +223,14 @@ func (r *Repo) ParseMod(modulePath string) error {
 			r.modules[require.Mod.Path] = newDep
 		}
 	}
+	m.goVersion = ""
+	if file.Go != nil {
+		m.goVersion = file.Go.Version
+	}
+	err = m.readGoSum(os.DirFS(modulePath))
+	if err != nil {
+		return fmt.Errorf("read go.sum: %w", err)
+	}
 	r.modules[file.Module.Mod.Path] = m
 	return nil
 }
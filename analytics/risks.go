@@ -0,0 +1,206 @@
+package analytics
+
+import (
+	"go/ast"
+	"go/token"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Risk is a kind of construct that deserves a closer look in review.
+type Risk int
+
+const (
+	RiskUnsafe   Risk = iota + 1 // imports unsafe
+	RiskCgo                      // imports C
+	RiskLinkname                 // a //go:linkname directive
+	RiskReflect                  // a use of reflect, only reported for packages using it heavily
+	RiskPanic                    // a call to panic outside package main
+	RiskOsExit                   // a call to os.Exit outside package main
+	RiskInit                     // an init function
+)
+
+//go:generate go run golang.org/x/tools/cmd/stringer@latest -type=Risk -trimprefix=Risk
+
+// Risks lists the risk kinds in the order they are presented.
+var Risks = []Risk{RiskUnsafe, RiskCgo, RiskLinkname, RiskReflect, RiskPanic, RiskOsExit, RiskInit}
+
+// HeavyReflect is the number of uses of reflect that makes a package use it heavily.
+const HeavyReflect = 10
+
+// ParseRisk returns the risk kind with the name, as returned by String.
+func ParseRisk(name string) (Risk, bool) {
+	for _, kind := range Risks {
+		if strings.EqualFold(kind.String(), name) {
+			return kind, true
+		}
+	}
+	return 0, false
+}
+
+type riskFacts struct {
+	Kind Risk
+	Line int
+}
+
+// RiskSite is a risky construct in a source file.
+type RiskSite struct {
+	Kind Risk
+	File *File
+	Line int
+}
+
+// PackageRisks is the risky constructs of a package.
+type PackageRisks struct {
+	Package *Package
+	Sites   []RiskSite
+}
+
+// Count returns the number of sites of the kind.
+func (pr PackageRisks) Count(kind Risk) int {
+	n := 0
+	for _, site := range pr.Sites {
+		if site.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// Risks returns the risky constructs in the production files of the package, by file and line.
+// Uses of reflect are left out unless there are at least HeavyReflect of them.
+func (p *Package) Risks() []RiskSite {
+	sites := make([]RiskSite, 0)
+	reflect := 0
+	for _, f := range p.files {
+		if f.Type == TestGo || f.facts == nil {
+			continue
+		}
+		for _, rf := range f.facts.Risks {
+			sites = append(sites, RiskSite{Kind: rf.Kind, File: f, Line: rf.Line})
+			if rf.Kind == RiskReflect {
+				reflect++
+			}
+		}
+	}
+	if reflect < HeavyReflect {
+		sites = slices.DeleteFunc(sites, func(site RiskSite) bool { return site.Kind == RiskReflect })
+	}
+	sort.SliceStable(sites, func(i, j int) bool {
+		if sites[i].File.Path != sites[j].File.Path {
+			return sites[i].File.Path < sites[j].File.Path
+		}
+		return sites[i].Line < sites[j].Line
+	})
+	return sites
+}
+
+// RiskKinds returns the kinds of risky constructs in the package, in the order of Risks.
+func (p *Package) RiskKinds() []Risk {
+	found := make(map[Risk]bool)
+	for _, site := range p.Risks() {
+		found[site.Kind] = true
+	}
+	kinds := make([]Risk, 0, len(found))
+	for _, kind := range Risks {
+		if found[kind] {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds
+}
+
+// RiskInventory returns the packages of the local modules with risky constructs of the kind,
+// or of any kind if kind is zero, sorted by module and package. Only the sites of the kind
+// are included.
+func (r *Repo) RiskInventory(kind Risk) []PackageRisks {
+	inventory := make([]PackageRisks, 0)
+	for _, mod := range r.modules {
+		if mod.Type != DepTypeLocal {
+			continue
+		}
+		for _, pkg := range mod.Packages {
+			sites := pkg.Risks()
+			if kind != 0 {
+				sites = slices.DeleteFunc(sites, func(site RiskSite) bool { return site.Kind != kind })
+			}
+			if len(sites) > 0 {
+				inventory = append(inventory, PackageRisks{Package: pkg, Sites: sites})
+			}
+		}
+	}
+	sort.Slice(inventory, func(i, j int) bool {
+		a, b := inventory[i].Package, inventory[j].Package
+		if a.Module.Path != b.Module.Path {
+			return a.Module.Path < b.Module.Path
+		}
+		return a.Name < b.Name
+	})
+	return inventory
+}
+
+// extractRisks finds the risky constructs of a file. Calls to panic and os.Exit are fine in
+// package main, where the program decides how to end.
+func extractRisks(file *ast.File, fset *token.FileSet) []riskFacts {
+	var risks []riskFacts
+	add := func(kind Risk, pos token.Pos) {
+		risks = append(risks, riskFacts{Kind: kind, Line: fset.Position(pos).Line})
+	}
+	isMain := file.Name.Name == "main"
+	var reflectName, osName string
+	for _, imp := range file.Imports {
+		p, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := path.Base(p)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		switch p {
+		case "unsafe":
+			add(RiskUnsafe, imp.Pos())
+		case "C":
+			add(RiskCgo, imp.Pos())
+		case "reflect":
+			reflectName = name
+		case "os":
+			osName = name
+		}
+	}
+	for _, cg := range file.Comments {
+		for _, c := range cg.List {
+			if strings.HasPrefix(c.Text, "//go:linkname ") {
+				add(RiskLinkname, c.Pos())
+			}
+		}
+	}
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == "init" {
+			add(RiskInit, fn.Name.Pos())
+		}
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch e := n.(type) {
+		case *ast.SelectorExpr:
+			if x, ok := e.X.(*ast.Ident); ok && x.Obj == nil {
+				switch {
+				case reflectName != "" && x.Name == reflectName:
+					add(RiskReflect, e.Pos())
+				case !isMain && osName != "" && x.Name == osName && e.Sel.Name == "Exit":
+					add(RiskOsExit, e.Pos())
+				}
+			}
+		case *ast.CallExpr:
+			// a panic declared in the file shadows the builtin and has an object
+			if id, ok := e.Fun.(*ast.Ident); ok && !isMain && id.Name == "panic" && id.Obj == nil {
+				add(RiskPanic, e.Pos())
+			}
+		}
+		return true
+	})
+	return risks
+}
@@ -0,0 +1,59 @@
+package analytics
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRisks(t *testing.T) {
+	reflectUses := strings.Repeat("\t_ = reflect.TypeOf(v)\n", HeavyReflect)
+	m := writeModule(t, "example.com/risky", map[string]string{
+		"lib.go": "package risky\n\nimport (\n\t\"os\"\n\t\"unsafe\"\n)\n\n//go:linkname now time.now\nfunc now() int64\n\n" +
+			"func init() {}\n\nfunc Fail() {\n\tpanic(\"no\")\n\tos.Exit(1)\n\t_ = unsafe.Sizeof(0)\n}\n",
+		"lib_test.go": "package risky\n\nimport \"os\"\n\nfunc init() {\n\tpanic(os.Args)\n}\n",
+		"cgo/cgo.go":  "package cgo\n\n// #include <stdio.h>\nimport \"C\"\n\nimport r \"reflect\"\n\nfunc Kind(v any) {\n\t_ = r.TypeOf(v)\n}\n",
+		"refl/refl.go": "package refl\n\nimport \"reflect\"\n\nfunc Use(v any) {\n" + reflectUses + "}\n\n" +
+			"func panic(v any) {}\n\nfunc Shadowed() {\n\tpanic(1)\n}\n",
+		"cmd/main.go": "package main\n\nimport \"os\"\n\nfunc init() {}\n\nfunc main() {\n\tpanic(\"fine\")\n\tos.Exit(2)\n}\n",
+	})
+	if err := m.LoadSource(); err != nil {
+		t.Fatalf("LoadSource: %v", err)
+	}
+	sites := func(name string) string {
+		pkg, ok := m.GetPackage(name)
+		if !ok {
+			t.Fatalf("package %s not found", name)
+		}
+		var got []string
+		for _, site := range pkg.Risks() {
+			got = append(got, fmt.Sprintf("%s:%d %s", site.File.Path, site.Line, site.Kind))
+		}
+		return strings.Join(got, ", ")
+	}
+	tests := map[string]string{
+		"risky": "lib.go:5 Unsafe, lib.go:8 Linkname, lib.go:11 Init, lib.go:14 Panic, lib.go:15 OsExit",
+		"cgo":   "cgo/cgo.go:4 Cgo",
+		"main":  "cmd/main.go:5 Init",
+	}
+	for name, want := range tests {
+		if got := sites(name); got != want {
+			t.Errorf("risks of %s: got %q, want %q", name, got, want)
+		}
+	}
+	refl, _ := m.GetPackage("refl")
+	if kinds := refl.RiskKinds(); len(kinds) != 1 || kinds[0] != RiskReflect || len(refl.Risks()) != HeavyReflect {
+		t.Errorf("risks of refl: %v", refl.Risks())
+	}
+
+	inventory := m.Repo.RiskInventory(RiskInit)
+	if len(inventory) != 2 || inventory[0].Package.Name != "main" || inventory[1].Package.Name != "risky" || inventory[1].Count(RiskInit) != 1 {
+		t.Errorf("init inventory: %+v", inventory)
+	}
+	if all := m.Repo.RiskInventory(0); len(all) != 4 {
+		t.Errorf("inventory: %d packages, want 4", len(all))
+	}
+	if kind, ok := ParseRisk("osexit"); !ok || kind != RiskOsExit {
+		t.Errorf("ParseRisk(osexit) = %v, %v", kind, ok)
+	}
+}
@@ -0,0 +1,345 @@
+package analytics
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Route is an HTTP route registered with net/http or gorilla/mux.
+type Route struct {
+	Methods     []string // empty for any method
+	Host        string
+	Path        string    // the pattern, after the prefixes of subrouters created in the same file
+	Prefix      bool      // the route matches every path starting with Path
+	Handler     string    // the handler as written
+	HandlerFunc *Function // the function or method handling the route, if the package declares it
+	File        *File
+	Line        int
+}
+
+// RouteGroup is the routes of a binary, or the routes in packages of a module no binary links.
+type RouteGroup struct {
+	Module *Module
+	Binary *Binary // nil for the routes no binary links
+	Routes []Route
+}
+
+type routeFacts struct {
+	Methods []string
+	Host    string
+	Path    string
+	Prefix  bool
+	Handler string
+	// HandlerName is the name of the function or method handling the route, empty if the
+	// handler is a function literal or an expression
+	HandlerName string
+	Line        int
+}
+
+// Method returns the methods of the route, or ANY.
+func (rt Route) Method() string {
+	if len(rt.Methods) == 0 {
+		return "ANY"
+	}
+	return strings.Join(rt.Methods, ", ")
+}
+
+// Matches tells if the route has the text in its methods, host, path or handler, ignoring case.
+func (rt Route) Matches(text string) bool {
+	text = strings.ToLower(text)
+	for _, s := range []string{rt.Method(), rt.Host, rt.Path, rt.Handler} {
+		if strings.Contains(strings.ToLower(s), text) {
+			return true
+		}
+	}
+	return false
+}
+
+// Routes returns the routes registered in the production files of the package, by file and line.
+func (p *Package) Routes() []Route {
+	routes := make([]Route, 0)
+	var funcs []*Function
+	for _, f := range p.files {
+		if f.Type != TestGo {
+			funcs = append(funcs, f.Functions()...)
+		}
+	}
+	for _, f := range p.files {
+		if f.Type == TestGo || f.facts == nil {
+			continue
+		}
+		for _, rf := range f.facts.Routes {
+			rt := Route{Methods: rf.Methods, Host: rf.Host, Path: rf.Path, Prefix: rf.Prefix, Handler: rf.Handler, File: f, Line: rf.Line}
+			for _, fn := range funcs {
+				if rf.HandlerName != "" && fn.Name == rf.HandlerName {
+					rt.HandlerFunc = fn
+					break
+				}
+			}
+			routes = append(routes, rt)
+		}
+	}
+	sortRoutes(routes)
+	return routes
+}
+
+// RouteCatalog returns the routes of the local modules matching the text, see Route.Matches,
+// or every route if it is empty. Routes are grouped by the binaries linking the packages
+// registering them, so a package of handlers shared by two services is listed under both;
+// routes in packages no binary links are grouped by module.
+func (r *Repo) RouteCatalog(text string) []RouteGroup {
+	groups := make([]RouteGroup, 0)
+	linked := make(map[*Package]bool)
+	matching := func(pkgs []*Package) []Route {
+		routes := make([]Route, 0)
+		for _, p := range pkgs {
+			for _, rt := range p.Routes() {
+				if text == "" || rt.Matches(text) {
+					routes = append(routes, rt)
+				}
+			}
+		}
+		sortRoutes(routes)
+		return routes
+	}
+	for _, b := range r.Binaries() {
+		main, _ := b.Module.GetPackage("main")
+		routes := make([]Route, 0)
+		for _, rt := range matching([]*Package{main}) {
+			if slices.Contains(b.Files, rt.File) {
+				routes = append(routes, rt)
+			}
+		}
+		routes = append(routes, matching(b.Deps)...)
+		for _, p := range b.Deps {
+			linked[p] = true
+		}
+		if len(routes) > 0 {
+			groups = append(groups, RouteGroup{Module: b.Module, Binary: b, Routes: routes})
+		}
+	}
+	mods := make([]*Module, 0)
+	for _, mod := range r.modules {
+		if mod.Type == DepTypeLocal {
+			mods = append(mods, mod)
+		}
+	}
+	sort.Slice(mods, func(i, j int) bool {
+		return mods[i].Path < mods[j].Path
+	})
+	for _, mod := range mods {
+		pkgs := make([]*Package, 0)
+		for _, p := range mod.Packages {
+			if !linked[p] && p.Name != "main" {
+				pkgs = append(pkgs, p)
+			}
+		}
+		if routes := matching(pkgs); len(routes) > 0 {
+			groups = append(groups, RouteGroup{Module: mod, Routes: routes})
+		}
+	}
+	return groups
+}
+
+func sortRoutes(routes []Route) {
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].File.Path != routes[j].File.Path {
+			return routes[i].File.Path < routes[j].File.Path
+		}
+		return routes[i].Line < routes[j].Line
+	})
+}
+
+// routeChainLink is a method call in a chain like r.HandleFunc("/", h).Methods("GET").
+type routeChainLink struct {
+	name string
+	args []ast.Expr
+}
+
+// extractRoutes finds the HTTP routes registered in a file: HandleFunc and Handle on a
+// net/http ServeMux, with Go 1.22 patterns like "GET /items/{id}", and on a gorilla/mux
+// router, with Methods, Path, PathPrefix, Host, Handler and HandlerFunc. Subrouters from
+// PathPrefix(...).Subrouter() assigned to a variable prefix the routes registered on the
+// variable in the same file; a subrouter passed to another function loses its prefix. Routes
+// assigned to a variable take the Methods, Host and Path called on the variable later on.
+func extractRoutes(file *ast.File, fset *token.FileSet) []routeFacts {
+	var routes []routeFacts
+	prefixes := make(map[string]string)
+	assigned := make(map[string]int) // index in routes by variable
+	inChain := make(map[*ast.CallExpr]bool)
+	// chain flattens a chain of method calls, returning the name of the variable it starts
+	// with, if it does
+	chain := func(call *ast.CallExpr) (string, []routeChainLink) {
+		var links []routeChainLink
+		var expr ast.Expr = call
+		for {
+			c, ok := expr.(*ast.CallExpr)
+			if !ok {
+				break
+			}
+			inChain[c] = true
+			sel, ok := c.Fun.(*ast.SelectorExpr)
+			if !ok {
+				break
+			}
+			links = append(links, routeChainLink{name: sel.Sel.Name, args: c.Args})
+			expr = sel.X
+		}
+		for i, j := 0, len(links)-1; i < j; i, j = i+1, j-1 {
+			links[i], links[j] = links[j], links[i]
+		}
+		if id, ok := expr.(*ast.Ident); ok {
+			return id.Name, links
+		}
+		return "", links
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch e := n.(type) {
+		case *ast.AssignStmt:
+			// api := r.PathPrefix("/api").Subrouter(), or a route assigned to a variable
+			if len(e.Lhs) != 1 || len(e.Rhs) != 1 {
+				return true
+			}
+			lhs, ok := e.Lhs[0].(*ast.Ident)
+			call, ok2 := e.Rhs[0].(*ast.CallExpr)
+			if !ok || !ok2 || inChain[call] {
+				return true
+			}
+			root, links := chain(call)
+			rf := routeFacts{Path: prefixes[root], Line: fset.Position(call.Pos()).Line}
+			if len(links) > 0 && links[len(links)-1].name == "Subrouter" {
+				applyRouteLinks(&rf, links)
+				prefixes[lhs.Name] = rf.Path
+				return true
+			}
+			// route := r.HandleFunc("/", h)
+			if applyRouteLinks(&rf, links) {
+				routes = append(routes, rf)
+				assigned[lhs.Name] = len(routes) - 1
+			}
+		case *ast.CallExpr:
+			if inChain[e] {
+				return true
+			}
+			root, links := chain(e)
+			// route.Methods("PUT") on a route assigned before
+			if i, ok := assigned[root]; ok {
+				applyRouteLinks(&routes[i], links)
+				return true
+			}
+			rf := routeFacts{Path: prefixes[root], Line: fset.Position(e.Pos()).Line}
+			if applyRouteLinks(&rf, links) {
+				routes = append(routes, rf)
+			}
+		}
+		return true
+	})
+	return routes
+}
+
+// applyRouteLinks applies the calls of a chain to a route, and tells if they register one.
+func applyRouteLinks(rf *routeFacts, links []routeChainLink) bool {
+	registered := false
+	for i, link := range links {
+		switch link.name {
+		case "HandleFunc", "Handle":
+			if len(link.args) != 2 {
+				return false
+			}
+			pattern, ok := stringLit(link.args[0])
+			if !ok || !strings.Contains(pattern, "/") {
+				return false
+			}
+			// Go 1.22 patterns: [METHOD ][HOST]/[PATH]
+			if method, rest, ok := strings.Cut(pattern, " "); ok {
+				rf.Methods = append(rf.Methods, method)
+				pattern = strings.TrimLeft(rest, " ")
+			}
+			if i := strings.Index(pattern, "/"); i > 0 {
+				rf.Host, pattern = pattern[:i], pattern[i:]
+			}
+			rf.Path += pattern
+			rf.Prefix = false
+			setRouteHandler(rf, link.args[1])
+			registered = true
+		case "Handler", "HandlerFunc":
+			// on a route like r.Path("/"), http.HandlerFunc(f) is a conversion
+			if i == 0 || len(link.args) != 1 {
+				return false
+			}
+			setRouteHandler(rf, link.args[0])
+			registered = true
+		case "Path", "PathPrefix", "Host":
+			if len(link.args) != 1 {
+				return false
+			}
+			s, ok := stringLit(link.args[0])
+			if !ok {
+				return false
+			}
+			switch link.name {
+			case "Host":
+				rf.Host = s
+			case "Path":
+				rf.Path += s
+			default:
+				rf.Path += s
+				rf.Prefix = true
+			}
+		case "Methods":
+			for _, arg := range link.args {
+				if m, ok := httpMethod(arg); ok {
+					rf.Methods = append(rf.Methods, m)
+				}
+			}
+		}
+	}
+	return registered
+}
+
+func setRouteHandler(rf *routeFacts, expr ast.Expr) {
+	rf.Handler = types.ExprString(expr)
+	rf.HandlerName = handlerName(expr)
+}
+
+// handlerName returns the name of the function or method a handler expression refers to,
+// looking through conversions and middleware with a single argument, like
+// http.HandlerFunc(index) or auth(s.handleAdmin).
+func handlerName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	case *ast.CallExpr:
+		if len(e.Args) == 1 {
+			return handlerName(e.Args[0])
+		}
+	}
+	return ""
+}
+
+// httpMethod returns the method of a string literal or a constant like http.MethodGet.
+func httpMethod(expr ast.Expr) (string, bool) {
+	if s, ok := stringLit(expr); ok {
+		return s, true
+	}
+	if sel, ok := expr.(*ast.SelectorExpr); ok && strings.HasPrefix(sel.Sel.Name, "Method") && len(sel.Sel.Name) > len("Method") {
+		return strings.ToUpper(strings.TrimPrefix(sel.Sel.Name, "Method")), true
+	}
+	return "", false
+}
+
+func stringLit(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
@@ -0,0 +1,109 @@
+package analytics
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRouteCatalog(t *testing.T) {
+	r := &Repo{modules: make(map[string]*Module), snapshots: make(map[string]*Module)}
+	add := func(path string, files map[string]string) *Module {
+		m := writeModule(t, path, files)
+		m.Repo = r
+		r.modules[path] = m
+		if err := m.LoadSource(); err != nil {
+			t.Fatalf("LoadSource: %v", err)
+		}
+		return m
+	}
+	add("example.com/web", map[string]string{
+		"routes.go": `package web
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type Server struct{}
+
+func (s *Server) handleItems(w http.ResponseWriter, r *http.Request) {}
+
+func (s *Server) handleItem(w http.ResponseWriter, r *http.Request) {}
+
+func Routes(s *Server) *mux.Router {
+	gmux := mux.NewRouter()
+	api := gmux.PathPrefix("/api").Subrouter()
+	v1 := api.PathPrefix("/v1").Subrouter()
+	v1.HandleFunc("/items", s.handleItems).Methods(http.MethodGet, "POST")
+	api.Handle("/item/{id}", auth(s.handleItem)).Methods(http.MethodDelete)
+	gmux.Host("admin.example.com").Path("/stats").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	gmux.PathPrefix("/").HandlerFunc(s.handleItems)
+	events.Handle("started", nil)
+	gmux.NotFoundHandler = http.HandlerFunc(s.handleItems)
+	route := gmux.HandleFunc("/assigned", s.handleItem)
+	route.Methods(http.MethodPut)
+	return gmux
+}
+
+func auth(h http.HandlerFunc) http.Handler { return h }
+`,
+		"unused/unused.go": "package unused\n\nimport \"net/http\"\n\nfunc init() {\n\thttp.HandleFunc(\"/unused\", nil)\n}\n",
+	})
+	add("example.com/svc", map[string]string{
+		"main.go": `package main
+
+import (
+	"net/http"
+
+	"example.com/web"
+)
+
+func main() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET example.com/hello/{name}", hello)
+	mux.Handle("/", web.Routes(&web.Server{}))
+	_ = http.ListenAndServe(":8080", mux)
+}
+
+func hello(w http.ResponseWriter, r *http.Request) {}
+`,
+		"main_test.go": "package main\n\nimport \"net/http\"\n\nfunc init() {\n\thttp.HandleFunc(\"/test\", nil)\n}\n",
+	})
+
+	var got []string
+	for _, g := range r.RouteCatalog("") {
+		name := g.Module.Path
+		if g.Binary != nil {
+			name = g.Binary.Name
+		}
+		for _, rt := range g.Routes {
+			handler := "-"
+			if rt.HandlerFunc != nil {
+				handler = fmt.Sprintf("%s:%d", rt.HandlerFunc.File.Path, rt.HandlerFunc.StartLine)
+			}
+			prefix := ""
+			if rt.Prefix {
+				prefix = "*"
+			}
+			got = append(got, fmt.Sprintf("%s: %s %s%s%s %s %s:%d %s", name, rt.Method(), rt.Host, rt.Path, prefix, rt.Handler, rt.File.Path, rt.Line, handler))
+		}
+	}
+	want := []string{
+		"svc: GET example.com/hello/{name} hello main.go:11 main.go:16",
+		"svc: ANY / web.Routes(&web.Server{}) main.go:12 -",
+		"svc: GET, POST /api/v1/items s.handleItems routes.go:19 routes.go:11",
+		"svc: DELETE /api/item/{id} auth(s.handleItem) routes.go:20 routes.go:13",
+		"svc: ANY admin.example.com/stats (func(w http.ResponseWriter, r *http.Request) literal) routes.go:21 -",
+		"svc: ANY /* s.handleItems routes.go:22 routes.go:11",
+		"svc: PUT /assigned s.handleItem routes.go:25 routes.go:13",
+		"example.com/web: ANY /unused nil unused/unused.go:6 -",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if groups := r.RouteCatalog("delete"); len(groups) != 1 || len(groups[0].Routes) != 1 || groups[0].Routes[0].Path != "/api/item/{id}" {
+		t.Errorf("search: %+v", groups)
+	}
+}
@@ -0,0 +1,152 @@
+package analytics
+
+import (
+	"fmt"
+	"github.com/perbu/gogrok/gitfs"
+	"github.com/perbu/gogrok/license"
+	"github.com/perbu/gogrok/modver"
+	mf "golang.org/x/mod/modfile"
+	"io/fs"
+	"log/slog"
+	"slices"
+)
+
+// maxSnapshots is the number of snapshots ModuleAt keeps, the least recently used go first.
+const maxSnapshots = 16
+
+// ModuleAt returns a local module as it was at a git ref (tag, branch or commit). The source is
+// read from the git object store, the working tree is left alone. Snapshots are analyzed on
+// first use and cached by commit, so a branch that moves is analyzed again, while refs to the
+// same commit share the snapshot and its Ref. An empty ref returns the module as checked out.
+//
+// Snapshots aren't part of the repo: they don't show up in module lists or reverse dependencies,
+// and they have no coverage, history or ownership data.
+func (r *Repo) ModuleAt(path, ref string) (*Module, error) {
+	current, ok := r.GetModule(path)
+	if !ok || current.Type != DepTypeLocal {
+		return nil, fmt.Errorf("no local module %s", path)
+	}
+	if ref == "" {
+		return current, nil
+	}
+	fsys, err := gitfs.Open(current.Location, ref)
+	if err != nil {
+		return nil, fmt.Errorf("gitfs.Open: %w", err)
+	}
+	key := path + "@" + fsys.Commit
+	if m, ok := r.snapshot(key, nil); ok {
+		return m, nil
+	}
+	// analyzing takes a while, other snapshots can be served meanwhile:
+	m := &Module{
+		Path:                      current.Path,
+		Location:                  current.Location,
+		Type:                      DepTypeLocal,
+		Repo:                      r,
+		Ref:                       ref,
+		Commit:                    fsys.Commit,
+		ReverseModuleDependencies: make([]*Module, 0),
+		versions:                  []string{ref},
+		LatestVersion:             current.LatestVersion,
+		codeowners:                current.codeowners,
+	}
+	err = m.parseModFS(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("parse go.mod at %s: %w", ref, err)
+	}
+	err = m.loadSource(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("load source at %s: %w", ref, err)
+	}
+	m.resolveImports()
+	m.license, err = license.Detect(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("license.Detect at %s: %w", ref, err)
+	}
+	slog.Info("analyzed module snapshot", "module", path, "ref", ref, "commit", fsys.Commit)
+	m, _ = r.snapshot(key, m)
+	return m, nil
+}
+
+// snapshot looks up a cached snapshot by path@commit and marks it as recently used. If it
+// isn't cached and m is set, m is cached, evicting the least recently used snapshot if there
+// are too many. It returns the cached snapshot, which is the first one cached when snapshots
+// of the same commit are analyzed concurrently.
+func (r *Repo) snapshot(key string, m *Module) (*Module, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if i := slices.Index(r.snapshotUse, key); i >= 0 {
+		r.snapshotUse = append(slices.Delete(r.snapshotUse, i, i+1), key)
+		return r.snapshots[key], true
+	}
+	if m == nil {
+		return nil, false
+	}
+	r.snapshots[key] = m
+	r.snapshotUse = append(r.snapshotUse, key)
+	if len(r.snapshotUse) > maxSnapshots {
+		delete(r.snapshots, r.snapshotUse[0])
+		r.snapshotUse = slices.Delete(r.snapshotUse, 0, 1)
+	}
+	return m, false
+}
+
+// parseModFS reads the dependencies of a snapshot from its go.mod and their hashes from its
+// go.sum. Dependencies are resolved against the modules of the repo, dependencies the repo
+// doesn't know about are added as detached external modules.
+func (m *Module) parseModFS(fsys fs.FS) error {
+	data, err := fs.ReadFile(fsys, "go.mod")
+	if err != nil {
+		return fmt.Errorf("fs.ReadFile: %w", err)
+	}
+	file, err := mf.Parse("go.mod", data, nil)
+	if err != nil {
+		return fmt.Errorf("modfile.Parse: %w", err)
+	}
+	m.Dependencies = make([]*Module, 0, len(file.Require))
+	m.requires = make(map[string]string, len(file.Require))
+	for _, require := range file.Require {
+		m.requires[require.Mod.Path] = require.Mod.Version
+		dep, ok := m.Repo.GetModule(require.Mod.Path)
+		if !ok {
+			dep = &Module{
+				Path:                      require.Mod.Path,
+				Type:                      DepTypeExternal,
+				Repo:                      m.Repo,
+				ReverseModuleDependencies: make([]*Module, 0),
+				versions:                  []string{require.Mod.Version},
+			}
+		}
+		m.Dependencies = append(m.Dependencies, dep)
+	}
+	m.goVersion = ""
+	if file.Go != nil {
+		m.goVersion = file.Go.Version
+	}
+	err = m.readGoSum(fsys)
+	if err != nil {
+		return fmt.Errorf("read go.sum: %w", err)
+	}
+	return nil
+}
+
+// Tags returns the tags of the git repository holding a local module, in semver order.
+func (m *Module) Tags() []string {
+	if m.Type != DepTypeLocal {
+		return nil
+	}
+	tags, err := modver.LocalTags(m.Location)
+	if err != nil {
+		slog.Debug("no local tags", "module", m.Path, "error", err)
+		return nil
+	}
+	return tags
+}
+
+// RefLabel names the snapshot for display: the ref, or "working tree".
+func (m *Module) RefLabel() string {
+	if m.Ref == "" {
+		return "working tree"
+	}
+	return m.Ref
+}
@@ -0,0 +1,116 @@
+package analytics
+
+import (
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func commitAll(t *testing.T, repo *git.Repository) {
+	t.Helper()
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.AddGlob("."); err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "dev", Email: "dev@example.com", When: time.Now()}
+	if _, err := wt.Commit("commit", &git.CommitOptions{Author: sig}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestModuleAt(t *testing.T) {
+	m := writeModule(t, "example.com/calc", map[string]string{
+		"go.mod":  "module example.com/calc\n\nrequire example.com/dep v1.0.0\n",
+		"calc.go": "package calc\n\nfunc Add(a, b int) int {\n\treturn a + b\n}\n",
+	})
+	repo, err := git.PlainInit(m.Location, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitAll(t, repo)
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CreateTag("v1.0.0", head.Hash(), nil); err != nil {
+		t.Fatal(err)
+	}
+	// the working tree moves on after the tag:
+	err = os.WriteFile(filepath.Join(m.Location, "sub.go"), []byte("package calc\n\nfunc Sub(a, b int) int {\n\treturn a - b\n}\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitAll(t, repo)
+	if err := m.LoadSource(); err != nil {
+		t.Fatalf("LoadSource: %v", err)
+	}
+
+	snap, err := m.Repo.ModuleAt(m.Path, "v1.0.0")
+	if err != nil {
+		t.Fatalf("ModuleAt: %v", err)
+	}
+	if snap == m || snap.Ref != "v1.0.0" || snap.Commit != head.Hash().String() {
+		t.Errorf("snapshot ref %q commit %s; want v1.0.0 at %s", snap.Ref, snap.Commit, head.Hash())
+	}
+	if got, want := snap.Files(), 1; got != want {
+		t.Errorf("snapshot Files() = %d, want %d", got, want)
+	}
+	if got, want := m.Files(), 2; got != want {
+		t.Errorf("working tree Files() = %d, want %d", got, want)
+	}
+	if len(snap.Dependencies) != 1 || snap.RequiredVersion(snap.Dependencies[0]) != "v1.0.0" {
+		t.Errorf("snapshot should require example.com/dep v1.0.0")
+	}
+	again, err := m.Repo.ModuleAt(m.Path, "v1.0.0")
+	if err != nil || again != snap {
+		t.Errorf("second ModuleAt should return the cached snapshot")
+	}
+	if byHash, err := m.Repo.ModuleAt(m.Path, head.Hash().String()); err != nil || byHash != snap {
+		t.Errorf("ModuleAt by commit hash should share the snapshot of v1.0.0")
+	}
+	if _, err := m.Repo.ModuleAt(m.Path, "v9.9.9"); err == nil {
+		t.Error("ModuleAt with an unknown ref should fail")
+	}
+
+	// a branch that moves is analyzed again:
+	master, err := m.Repo.ModuleAt(m.Path, "master")
+	if err != nil || master.Files() != 2 {
+		t.Fatalf("ModuleAt(master): %v", err)
+	}
+	err = os.WriteFile(filepath.Join(m.Location, "mul.go"), []byte("package calc\n\nfunc Mul(a, b int) int {\n\treturn a * b\n}\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitAll(t, repo)
+	if moved, err := m.Repo.ModuleAt(m.Path, "master"); err != nil || moved == master || moved.Files() != 3 {
+		t.Errorf("ModuleAt(master) after a commit should analyze the new commit")
+	}
+}
+
+func TestSnapshotEviction(t *testing.T) {
+	r := &Repo{modules: make(map[string]*Module), snapshots: make(map[string]*Module)}
+	for i := range maxSnapshots + 1 {
+		key := fmt.Sprintf("example.com/calc@%d", i)
+		r.snapshot(key, &Module{Commit: key})
+		if i == 0 {
+			// keep the first one in use:
+			continue
+		}
+		if _, ok := r.snapshot("example.com/calc@0", nil); !ok {
+			t.Fatalf("snapshot 0 evicted after %d more", i)
+		}
+	}
+	if len(r.snapshots) != maxSnapshots || len(r.snapshotUse) != maxSnapshots {
+		t.Errorf("%d snapshots cached, want %d", len(r.snapshots), maxSnapshots)
+	}
+	if _, ok := r.snapshot("example.com/calc@1", nil); ok {
+		t.Error("the least recently used snapshot should be evicted")
+	}
+}
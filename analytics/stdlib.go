@@ -0,0 +1,136 @@
+package analytics
+
+import (
+	"slices"
+	"sort"
+)
+
+// StdlibPath is the module path the standard library is recorded under, like the go command
+// calls it.
+const StdlibPath = "std"
+
+// DeprecatedStdlib are the standard library packages flagged by default, with the reason.
+var DeprecatedStdlib = map[string]string{
+	"crypto/des":      "DES and triple DES are insecure, use crypto/aes",
+	"crypto/dsa":      "deprecated since Go 1.16, DSA is insecure",
+	"crypto/md5":      "MD5 is cryptographically broken, use crypto/sha256 unless it's for a legacy format",
+	"crypto/rc4":      "RC4 is cryptographically broken",
+	"crypto/sha1":     "SHA-1 is cryptographically broken, use crypto/sha256 unless it's for a legacy format",
+	"io/ioutil":       "deprecated since Go 1.16, use io and os",
+	"log/syslog":      "frozen, not accepting new features",
+	"math/rand":       "superseded by math/rand/v2 in Go 1.22, use crypto/rand for secrets",
+	"net/rpc":         "frozen, not accepting new features",
+	"net/rpc/jsonrpc": "frozen, not accepting new features",
+	"net/smtp":        "frozen, not accepting new features",
+	"syscall":         "deprecated, use golang.org/x/sys",
+}
+
+// stdlibModule returns the pseudo-module holding the standard library packages imported by the
+// modules of the repo, creating it on first use.
+func (r *Repo) stdlibModule() *Module {
+	m, ok := r.modules[StdlibPath]
+	if !ok {
+		m = &Module{
+			Path:                      StdlibPath,
+			Type:                      DepTypeStdlib,
+			Repo:                      r,
+			ReverseModuleDependencies: make([]*Module, 0),
+			versions:                  make([]string, 0),
+			packageDirs:               make(map[string]*Package),
+		}
+		r.modules[StdlibPath] = m
+	}
+	return m
+}
+
+// stdlibPackage returns the standard library package with the import path. Its name is the
+// import path, as crypto/rand and math/rand share a name. Unless create is set, only packages
+// already imported by a module of the repo are returned: snapshots are analyzed while the repo
+// is served and mustn't change it.
+func (r *Repo) stdlibPackage(imp string, create bool) (*Package, bool) {
+	if !create {
+		m, ok := r.modules[StdlibPath]
+		if !ok {
+			return nil, false
+		}
+		p, ok := m.packageDirs[imp]
+		return p, ok
+	}
+	m := r.stdlibModule()
+	if p, ok := m.packageDirs[imp]; ok {
+		return p, true
+	}
+	p := &Package{
+		Name:                imp,
+		Location:            imp,
+		Module:              m,
+		files:               make([]*File, 0),
+		ReverseDependencies: make([]*Package, 0),
+	}
+	m.Packages = append(m.Packages, p)
+	m.packageDirs[imp] = p
+	return p, true
+}
+
+// StdlibUsage is a standard library package and the local packages importing it.
+type StdlibUsage struct {
+	Package    *Package
+	Deprecated string     // why the package is flagged, empty if it isn't
+	Importers  []*Package // local packages importing it, sorted by module and name
+	Modules    []*Module  // local modules importing it, sorted by path
+}
+
+// Deprecation returns why the standard library package with the import path is flagged, by the
+// configuration or by default.
+func (r *Repo) Deprecation(imp string) (string, bool) {
+	if slices.Contains(r.config.FlagStdlib, imp) {
+		return "flagged by the configuration", true
+	}
+	reason, ok := DeprecatedStdlib[imp]
+	return reason, ok
+}
+
+// StdlibUsage returns the standard library packages imported by local modules, the flagged
+// ones first, then by import path.
+func (r *Repo) StdlibUsage() []StdlibUsage {
+	std, ok := r.modules[StdlibPath]
+	if !ok {
+		return make([]StdlibUsage, 0)
+	}
+	usage := make([]StdlibUsage, 0, len(std.Packages))
+	for _, p := range std.Packages {
+		u := StdlibUsage{Package: p, Importers: make([]*Package, 0), Modules: make([]*Module, 0)}
+		u.Deprecated, _ = r.Deprecation(p.Name)
+		for _, imp := range p.ReverseDependencies {
+			if imp.Module.Type != DepTypeLocal {
+				continue
+			}
+			u.Importers = append(u.Importers, imp)
+			if !slices.Contains(u.Modules, imp.Module) {
+				u.Modules = append(u.Modules, imp.Module)
+			}
+		}
+		if len(u.Importers) == 0 {
+			continue
+		}
+		sort.Slice(u.Importers, func(i, j int) bool {
+			a, b := u.Importers[i], u.Importers[j]
+			if a.Module.Path != b.Module.Path {
+				return a.Module.Path < b.Module.Path
+			}
+			return a.Name < b.Name
+		})
+		sort.Slice(u.Modules, func(i, j int) bool {
+			return u.Modules[i].Path < u.Modules[j].Path
+		})
+		usage = append(usage, u)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		a, b := usage[i], usage[j]
+		if (a.Deprecated != "") != (b.Deprecated != "") {
+			return a.Deprecated != ""
+		}
+		return a.Package.Name < b.Package.Name
+	})
+	return usage
+}
@@ -0,0 +1,85 @@
+package analytics
+
+import "testing"
+
+func TestStdlibUsage(t *testing.T) {
+	r := &Repo{
+		modules:   make(map[string]*Module),
+		snapshots: make(map[string]*Module),
+		config:    Config{FlagStdlib: []string{"net/http/cgi"}},
+	}
+	add := func(path string, files map[string]string) *Module {
+		m := writeModule(t, path, files)
+		m.Repo = r
+		r.modules[path] = m
+		if err := m.LoadSource(); err != nil {
+			t.Fatalf("LoadSource: %v", err)
+		}
+		return m
+	}
+	add("example.com/lib", map[string]string{
+		"lib.go":      "package lib\n\nimport (\n\t\"crypto/rand\"\n\t\"math/rand\"\n)\n",
+		"cgi/cgi.go":  "package cgi\n\nimport \"net/http/cgi\"\n",
+		"lib_test.go": "package lib\n\nimport \"testing\"\n",
+	})
+	add("example.com/app", map[string]string{
+		"main.go": "package main\n\n// #include <stdio.h>\nimport \"C\"\n\nimport (\n\t\"fmt\"\n\tf \"fmt\"\n\t\"io/ioutil\"\n\n\t\"example.com/lib\"\n)\n",
+	})
+	for _, mod := range r.modules {
+		mod.resolveImports()
+	}
+	r.reverseDeps()
+
+	std, ok := r.GetModule(StdlibPath)
+	if !ok || std.Type != DepTypeStdlib {
+		t.Fatalf("no standard library module")
+	}
+	app, _ := r.modules["example.com/app"].GetPackage("main")
+	if imports := app.GetFiles()[0].Imports; len(imports) != 3 || imports[0].Name != "fmt" || imports[2].Module.Path != "example.com/lib" {
+		t.Errorf("imports of app: %+v", imports)
+	}
+
+	usage := r.StdlibUsage()
+	var got []string
+	for _, u := range usage {
+		got = append(got, u.Package.Name)
+	}
+	want := []string{"io/ioutil", "math/rand", "net/http/cgi", "crypto/rand", "fmt", "testing"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+	if usage[2].Deprecated != "flagged by the configuration" || usage[3].Deprecated != "" {
+		t.Errorf("flags: %q, %q", usage[2].Deprecated, usage[3].Deprecated)
+	}
+	if u := usage[1]; len(u.Modules) != 1 || u.Modules[0].Path != "example.com/lib" || len(u.Importers) != 1 || u.Importers[0].Name != "lib" {
+		t.Errorf("math/rand: %+v", u)
+	}
+}
+
+func TestStdlibInSnapshot(t *testing.T) {
+	r := &Repo{modules: make(map[string]*Module), snapshots: make(map[string]*Module)}
+	m := writeModule(t, "example.com/svc", map[string]string{"main.go": "package main\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n"})
+	m.Repo = r
+	r.modules[m.Path] = m
+	if _, ok := r.stdlibPackage("fmt", true); !ok {
+		t.Fatal("stdlibPackage")
+	}
+	// a snapshot links to the packages the repo knows, and leaves the repo alone:
+	m.Ref = "v1.0.0"
+	if err := m.LoadSource(); err != nil {
+		t.Fatal(err)
+	}
+	m.resolveImports()
+	main, _ := m.GetPackage("main")
+	if imports := main.GetFiles()[0].Imports; len(imports) != 1 || imports[0].Name != "fmt" {
+		t.Errorf("imports: %+v", imports)
+	}
+	if std := r.modules[StdlibPath]; len(std.Packages) != 1 {
+		t.Errorf("snapshot added standard library packages: %+v", std.Packages)
+	}
+}
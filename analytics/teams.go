@@ -0,0 +1,115 @@
+package analytics
+
+import (
+	"github.com/perbu/gogrok/codeowners"
+	"log/slog"
+	"slices"
+	"sort"
+)
+
+// loadCodeowners reads the CODEOWNERS file of every local module.
+func (r *Repo) loadCodeowners() {
+	for _, mod := range r.modules {
+		if mod.Type != DepTypeLocal {
+			continue
+		}
+		rs, err := codeowners.Load(mod.Location)
+		if err != nil {
+			slog.Warn("unreadable CODEOWNERS", "module", mod.Path, "error", err)
+			continue
+		}
+		mod.codeowners = rs
+	}
+}
+
+// Teams returns the owners of the file according to CODEOWNERS.
+func (f *File) Teams() []string {
+	return f.Module.codeowners.Owners(f.Path)
+}
+
+// Teams returns the owners of any of the files in the package.
+func (p *Package) Teams() []string {
+	teams := make([]string, 0)
+	for _, f := range p.files {
+		teams = append(teams, f.Teams()...)
+	}
+	slices.Sort(teams)
+	return slices.Compact(teams)
+}
+
+// Teams returns the owners of the module, which are the owners of its go.mod.
+func (m *Module) Teams() []string {
+	return m.codeowners.Owners("go.mod")
+}
+
+// OwnedBy tells if the team owns the module or any of its packages.
+func (m *Module) OwnedBy(team string) bool {
+	if slices.Contains(m.Teams(), team) {
+		return true
+	}
+	for _, pkg := range m.Packages {
+		if slices.Contains(pkg.Teams(), team) {
+			return true
+		}
+	}
+	return false
+}
+
+// Teams returns every owner mentioned for the local modules.
+func (r *Repo) Teams() []string {
+	teams := make([]string, 0)
+	for _, mod := range r.modules {
+		if mod.Type != DepTypeLocal {
+			continue
+		}
+		teams = append(teams, mod.Teams()...)
+		for _, pkg := range mod.Packages {
+			teams = append(teams, pkg.Teams()...)
+		}
+	}
+	slices.Sort(teams)
+	return slices.Compact(teams)
+}
+
+// TeamSummary aggregates the code a team owns. Files count towards the team owning them,
+// dependencies towards the team owning the go.mod that requires them.
+type TeamSummary struct {
+	Team                 string
+	Modules              []*Module // modules the team owns or owns packages in
+	Files                int
+	LoC                  int
+	Complexity           float32 // average complexity of the files owned
+	OutdatedDependencies []OutdatedDependency
+}
+
+// TeamSummary aggregates LoC, complexity and outdated dependencies for a team.
+func (r *Repo) TeamSummary(team string) TeamSummary {
+	sum := TeamSummary{Team: team, Modules: make([]*Module, 0), OutdatedDependencies: make([]OutdatedDependency, 0)}
+	complexity := float32(0)
+	for _, mod := range r.modules {
+		if mod.Type != DepTypeLocal || !mod.OwnedBy(team) {
+			continue
+		}
+		sum.Modules = append(sum.Modules, mod)
+		if slices.Contains(mod.Teams(), team) {
+			sum.OutdatedDependencies = append(sum.OutdatedDependencies, mod.OutdatedDependencies()...)
+		}
+		for _, pkg := range mod.Packages {
+			for _, f := range pkg.countedFiles() {
+				if !slices.Contains(f.Teams(), team) {
+					continue
+				}
+				sum.Files++
+				sum.LoC += f.Lines()
+				complexity += f.CalculateComplexity()
+			}
+		}
+	}
+	if sum.Files > 0 {
+		sum.Complexity = complexity / float32(sum.Files)
+	}
+	sort.Slice(sum.Modules, func(i, j int) bool {
+		return sum.Modules[i].Path < sum.Modules[j].Path
+	})
+	return sum
+}
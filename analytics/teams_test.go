@@ -0,0 +1,33 @@
+package analytics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTeamSummary(t *testing.T) {
+	m := writeModule(t, "example.com/owned", map[string]string{
+		".github/CODEOWNERS": "* @org/core\ndb/ @org/dba\n",
+		"main.go":            "package main\n\nfunc main() {}\n",
+		"db/db.go":           "package db\n\nfunc Open() {}\n",
+	})
+	if err := m.LoadSource(); err != nil {
+		t.Fatalf("LoadSource: %v", err)
+	}
+	m.Repo.loadCodeowners()
+
+	if got := m.Repo.Teams(); !reflect.DeepEqual(got, []string{"@org/core", "@org/dba"}) {
+		t.Errorf("Teams() = %v", got)
+	}
+	if got := m.Teams(); !reflect.DeepEqual(got, []string{"@org/core"}) {
+		t.Errorf("Module.Teams() = %v, want the owners of go.mod", got)
+	}
+	if !m.OwnedBy("@org/dba") {
+		t.Error("OwnedBy(@org/dba) = false, the team owns the db package")
+	}
+	sum := m.Repo.TeamSummary("@org/dba")
+	if sum.Files != 1 || sum.LoC != 3 || len(sum.Modules) != 1 {
+		t.Errorf("TeamSummary(@org/dba) = %d files, %d lines, %d modules; want 1, 3, 1",
+			sum.Files, sum.LoC, len(sum.Modules))
+	}
+}
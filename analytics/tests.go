@@ -0,0 +1,202 @@
+package analytics
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TestKind is the kind of function the go tool picks up from a _test.go file.
+type TestKind int
+
+const (
+	UnitTest TestKind = iota + 1
+	Benchmark
+	FuzzTarget
+	Example
+)
+
+//go:generate go run golang.org/x/tools/cmd/stringer@latest -type=TestKind
+
+// TestKinds lists the test kinds in the order they are presented.
+var TestKinds = []TestKind{UnitTest, Benchmark, FuzzTarget, Example}
+
+// TestFunc is a test, benchmark, fuzz target or example found in a test file.
+type TestFunc struct {
+	Name string
+	Kind TestKind
+	File *File
+	Line int
+}
+
+// TestFunctions returns the tests, benchmarks, fuzz targets and examples declared in the file.
+func (f *File) TestFunctions() []TestFunc {
+	if f.Type != TestGo || f.facts == nil {
+		return nil
+	}
+	funcs := make([]TestFunc, 0)
+	for _, fn := range f.facts.Functions {
+		if fn.Receiver != "" {
+			continue
+		}
+		kind, ok := testKind(fn.Name)
+		if !ok {
+			continue
+		}
+		funcs = append(funcs, TestFunc{Name: fn.Name, Kind: kind, File: f, Line: fn.StartLine})
+	}
+	return funcs
+}
+
+// testKind classifies a function name the way the go tool does.
+func testKind(name string) (TestKind, bool) {
+	switch {
+	case isTest(name, "Test"):
+		return UnitTest, true
+	case isTest(name, "Benchmark"):
+		return Benchmark, true
+	case isTest(name, "Fuzz"):
+		return FuzzTarget, true
+	case isTest(name, "Example"):
+		return Example, true
+	}
+	return 0, false
+}
+
+// isTest tells whether name looks like a test (or benchmark, according to prefix).
+// It is a Test (say) if there is a character after Test that is not a lower-case letter.
+func isTest(name, prefix string) bool {
+	if !strings.HasPrefix(name, prefix) {
+		return false
+	}
+	if len(name) == len(prefix) { // "Test" is ok
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(name[len(prefix):])
+	return !unicode.IsLower(r)
+}
+
+// TestFunctions returns all test functions of the package, sorted by kind and name.
+func (p *Package) TestFunctions() []TestFunc {
+	funcs := make([]TestFunc, 0)
+	for _, file := range p.files {
+		funcs = append(funcs, file.TestFunctions()...)
+	}
+	sort.Slice(funcs, func(i, j int) bool {
+		if funcs[i].Kind != funcs[j].Kind {
+			return funcs[i].Kind < funcs[j].Kind
+		}
+		return funcs[i].Name < funcs[j].Name
+	})
+	return funcs
+}
+
+// TestFunctionsOfKind returns the test functions of the given kind.
+func (p *Package) TestFunctionsOfKind(kind TestKind) []TestFunc {
+	funcs := make([]TestFunc, 0)
+	for _, tf := range p.TestFunctions() {
+		if tf.Kind == kind {
+			funcs = append(funcs, tf)
+		}
+	}
+	return funcs
+}
+
+// HasTests reports whether the package has at least one test, fuzz target or example.
+// Benchmarks alone don't count, they don't verify anything.
+func (p *Package) HasTests() bool {
+	for _, tf := range p.TestFunctions() {
+		if tf.Kind != Benchmark {
+			return true
+		}
+	}
+	return false
+}
+
+// TestLines returns the number of lines in the test files of the package.
+func (p *Package) TestLines() int {
+	lines := 0
+	for _, file := range p.files {
+		if file.Type == TestGo {
+			lines += file.Lines()
+		}
+	}
+	return lines
+}
+
+// ProductionLines returns the number of lines in the non-test files of the package.
+func (p *Package) ProductionLines() int {
+	lines := 0
+	for _, file := range p.files {
+		if file.Type != TestGo {
+			lines += file.Lines()
+		}
+	}
+	return lines
+}
+
+// TestRatio returns test LoC divided by production LoC.
+func (p *Package) TestRatio() float32 {
+	return testRatio(p.TestLines(), p.ProductionLines())
+}
+
+func (m *Module) TestLines() int {
+	lines := 0
+	for _, pkg := range m.Packages {
+		lines += pkg.TestLines()
+	}
+	return lines
+}
+
+func (m *Module) ProductionLines() int {
+	lines := 0
+	for _, pkg := range m.Packages {
+		lines += pkg.ProductionLines()
+	}
+	return lines
+}
+
+// TestRatio returns test LoC divided by production LoC for the whole module.
+func (m *Module) TestRatio() float32 {
+	return testRatio(m.TestLines(), m.ProductionLines())
+}
+
+// UntestedPackages returns the packages of the module that have production code but no tests.
+func (m *Module) UntestedPackages() []*Package {
+	pkgs := make([]*Package, 0)
+	for _, pkg := range m.Packages {
+		if pkg.ProductionLines() > 0 && !pkg.HasTests() {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	sort.Slice(pkgs, func(i, j int) bool {
+		return pkgs[i].Name < pkgs[j].Name
+	})
+	return pkgs
+}
+
+// UntestedPackages returns the untested packages across all local modules, sorted by module.
+func (r *Repo) UntestedPackages() []*Package {
+	pkgs := make([]*Package, 0)
+	for _, mod := range r.ModuleFilter(DepTypeLocal, "") {
+		pkgs = append(pkgs, mod.UntestedPackages()...)
+	}
+	return pkgs
+}
+
+func testRatio(test, production int) float32 {
+	if production == 0 {
+		return 0
+	}
+	return float32(test) / float32(production)
+}
+
+// counted tells if the file is part of the production metrics. Test files are left out
+// unless the repo is configured to include them.
+func (f *File) counted() bool {
+	if f.Type != TestGo {
+		return true
+	}
+	return f.Module != nil && f.Module.Repo != nil && f.Module.Repo.config.IncludeTests
+}
@@ -0,0 +1,95 @@
+package analytics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTestKind(t *testing.T) {
+	tests := []struct {
+		name     string
+		wantKind TestKind
+		wantOk   bool
+	}{
+		{name: "Test", wantKind: UnitTest, wantOk: true},
+		{name: "TestFoo", wantKind: UnitTest, wantOk: true},
+		{name: "Test_foo", wantKind: UnitTest, wantOk: true},
+		{name: "Testify", wantOk: false},
+		{name: "BenchmarkParse", wantKind: Benchmark, wantOk: true},
+		{name: "FuzzParse", wantKind: FuzzTarget, wantOk: true},
+		{name: "Example", wantKind: Example, wantOk: true},
+		{name: "ExampleRepo_Parse", wantKind: Example, wantOk: true},
+		{name: "helper", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, ok := testKind(tt.name)
+			if kind != tt.wantKind || ok != tt.wantOk {
+				t.Errorf("testKind(%q) = %v, %v; want %v, %v", tt.name, kind, ok, tt.wantKind, tt.wantOk)
+			}
+		})
+	}
+}
+
+// writeModule writes files into a temporary directory and returns a local module rooted there.
+func writeModule(t *testing.T, modPath string, files map[string]string) *Module {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := &Repo{modules: make(map[string]*Module), snapshots: make(map[string]*Module)}
+	m := &Module{Path: modPath, Location: dir, Type: DepTypeLocal, Repo: r}
+	r.modules[modPath] = m
+	return m
+}
+
+func TestPackageTestMetrics(t *testing.T) {
+	m := writeModule(t, "example.com/calc", map[string]string{
+		"calc.go": "package calc\n\nfunc Add(a, b int) int {\n\treturn a + b\n}\n",
+		"calc_test.go": "package calc_test\n\nimport \"testing\"\n\n" +
+			"func TestAdd(t *testing.T) {}\n\nfunc BenchmarkAdd(b *testing.B) {}\n\nfunc Example() {}\n",
+		"util/util.go": "package util\n\nfunc Nop() {}\n",
+	})
+	if err := m.LoadSource(); err != nil {
+		t.Fatalf("LoadSource: %v", err)
+	}
+	calc, ok := m.GetPackage("calc")
+	if !ok {
+		t.Fatal("package calc not found")
+	}
+	if _, ok := m.GetPackage("calc_test"); ok {
+		t.Error("external test package should be folded into calc")
+	}
+	if got := len(calc.TestFunctions()); got != 3 {
+		t.Errorf("TestFunctions() = %d, want 3", got)
+	}
+	if got := len(calc.TestFunctionsOfKind(Benchmark)); got != 1 {
+		t.Errorf("TestFunctionsOfKind(Benchmark) = %d, want 1", got)
+	}
+	if got := calc.Lines(); got != 5 {
+		t.Errorf("Lines() = %d, want 5 (tests excluded)", got)
+	}
+	if got := calc.Files(); got != 1 {
+		t.Errorf("Files() = %d, want 1 (tests excluded)", got)
+	}
+	if got := calc.TestLines(); got != 9 {
+		t.Errorf("TestLines() = %d, want 9", got)
+	}
+	untested := m.UntestedPackages()
+	if len(untested) != 1 || untested[0].Name != "util" {
+		t.Errorf("UntestedPackages() = %v, want [util]", untested)
+	}
+
+	m.Repo.config.IncludeTests = true
+	if got := calc.Lines(); got != 14 {
+		t.Errorf("Lines() = %d, want 14 with tests included", got)
+	}
+}
@@ -1,14 +1,41 @@
 package analytics
 
 import (
+	"github.com/perbu/gogrok/codeowners"
+	"github.com/perbu/gogrok/history"
+	"github.com/perbu/gogrok/license"
 	"github.com/perbu/gogrok/modver"
-	"go/ast"
+	"golang.org/x/tools/cover"
+	"io/fs"
+	"sync"
+	"time"
 )
 
 type Repo struct {
-	modules    map[string]*Module
-	basePath   string
-	modTracker *modver.ModTracker
+	modules     map[string]*Module
+	basePath    string
+	modTracker  *modver.ModTracker
+	config      Config
+	mu          sync.Mutex         // guards snapshots and snapshotUse
+	snapshots   map[string]*Module // modules analyzed at a git ref, keyed by path@commit
+	snapshotUse []string           // keys of snapshots, least recently used first
+	cache       *factCache         // file facts from earlier runs, nil if caching is off
+	previous    *Repo              // during a rebuild, the analysis being replaced
+	changed     map[string]bool    // during a rebuild, the locations of the changed checkouts
+	binaries    binaryIndex        // the binaries of the local modules, found on first use
+}
+
+// Config holds the options that control the analysis.
+type Config struct {
+	IncludeTests  bool            // count test files in the production metrics (LoC, files, complexity)
+	CoverageDir   string          // directory with coverage profiles to import, empty to skip
+	HistoryWindow time.Duration   // how far back to walk the git history, 0 to skip
+	Ownership     bool            // run git blame to find code ownership and bus factors
+	CacheFile     string          // bolt database caching file facts between runs, empty to skip
+	ModCache      string          // module cache (GOMODCACHE) to read external modules from, empty to skip
+	LicensePolicy *license.Policy // licenses allowed and denied, nil allows every license
+	LoadExternal  bool            // load the source of external modules from ModCache too
+	FlagStdlib    []string        // standard library packages to flag on top of DeprecatedStdlib
 }
 
 type Module struct {
@@ -17,10 +44,26 @@ type Module struct {
 	versions                  []string   // module versions
 	Dependencies              []*Module  // list of dependencies
 	Packages                  []*Package // list of packages in the module
-	Type                      DepType    // either a local (on-disk) or external (remote) module
+	Type                      DepType    // a local (on-disk) or external (remote) module, or the standard library
 	Repo                      *Repo      // reference to the repo
 	ReverseModuleDependencies []*Module  // List of modules that depend on this module
 	LatestVersion             string     // latest version of the module
+	Ref                       string     // git ref the module was analyzed at, empty for the working tree
+	Commit                    string     // commit hash Ref resolved to
+	history                   *history.Stats
+	fileHistory               map[string]*history.Stats    // git history by file path, kept for rebuilds
+	fileOwnership             map[string]history.Ownership // git blame by file path, kept for rebuilds
+	lastCommits               map[string]time.Time         // last commit per author, populated with ownership
+	requires                  map[string]string            // required version by dependency path, from go.mod
+	goSum                     []SumEntry                   // the lines of go.sum
+	badSumLines               []int                        // numbers of the go.sum lines that didn't parse
+	hasGoSum                  bool                         // there is a go.sum, possibly empty
+	goVersion                 string                       // the go directive of go.mod, ie. 1.22
+	codeowners                *codeowners.Ruleset          // CODEOWNERS of the checkout, nil if there is none
+	license                   license.Info                 // license files in the root of the module
+	fsys                      fs.FS                        // the working tree or git snapshot the source was loaded from
+	packageDirs               map[string]*Package          // packages by directory relative to the module, set by loadSource
+	dockerfiles               map[string]string            // Dockerfile contents by path, set by loadSource for local modules
 }
 
 type Package struct {
@@ -32,13 +75,17 @@ type Package struct {
 }
 
 type File struct {
-	Name    string     // file name, not including the path
-	Imports []*Package // list of imported packages
-	Package *Package   // reference to the package this file belongs to
-	Module  *Module    // reference to the module
-	source  []string   // file contents, split into lines, allow for references to files and lines
-	ast     *ast.File
-	Type    FileType
+	Name      string     // file name, not including the path
+	Path      string     // file path, relative to the module
+	Imports   []*Package // list of imported packages
+	Package   *Package   // reference to the package this file belongs to
+	Module    *Module    // reference to the module
+	Type      FileType
+	facts     *fileFacts           // what the analysis needs from the source, see extractFacts
+	functions []*Function          // functions declared in the file
+	coverage  []cover.ProfileBlock // statement coverage blocks from imported coverage profiles
+	history   *history.Stats       // git history within the configured window
+	ownership history.Ownership    // lines per author from git blame
 }
 
 type FileType int
@@ -0,0 +1,132 @@
+package codeowners
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Locations are the places GitHub looks for a CODEOWNERS file, in order of precedence.
+var Locations = []string{
+	".github/CODEOWNERS",
+	"CODEOWNERS",
+	"docs/CODEOWNERS",
+}
+
+// Rule is a single line in a CODEOWNERS file.
+type Rule struct {
+	Pattern string
+	Owners  []string // teams (@org/team), users (@user) or emails
+	re      *regexp.Regexp
+}
+
+// Ruleset is a parsed CODEOWNERS file.
+type Ruleset struct {
+	Rules []Rule
+}
+
+// Load reads the CODEOWNERS file of the checkout in dir. It returns nil and no error if there
+// is no CODEOWNERS file.
+func Load(dir string) (*Ruleset, error) {
+	for _, loc := range Locations {
+		f, err := os.Open(filepath.Join(dir, loc))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("os.Open: %w", err)
+		}
+		rs, err := Parse(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", loc, err)
+		}
+		return rs, nil
+	}
+	return nil, nil
+}
+
+// Parse parses a CODEOWNERS file.
+func Parse(r io.Reader) (*Ruleset, error) {
+	rs := &Ruleset{Rules: make([]Rule, 0)}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// strip trailing comments:
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		fields := strings.Fields(line)
+		re, err := compile(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", fields[0], err)
+		}
+		rs.Rules = append(rs.Rules, Rule{Pattern: fields[0], Owners: fields[1:], re: re})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanner: %w", err)
+	}
+	return rs, nil
+}
+
+// Owners returns the owners of a slash separated path relative to the root of the checkout.
+// As on GitHub the last matching rule wins. A rule without owners clears the ownership.
+func (rs *Ruleset) Owners(path string) []string {
+	if rs == nil {
+		return nil
+	}
+	for i := len(rs.Rules) - 1; i >= 0; i-- {
+		if rs.Rules[i].re.MatchString(path) {
+			return rs.Rules[i].Owners
+		}
+	}
+	return nil
+}
+
+// compile turns a CODEOWNERS pattern, which follows the gitignore rules, into a regexp.
+// A pattern matches a path if it matches the path itself or one of its parent directories.
+func compile(pattern string) (*regexp.Regexp, error) {
+	trimmed := strings.TrimSuffix(pattern, "/")
+	// a leading or middle slash anchors the pattern to the root:
+	anchored := strings.Contains(trimmed, "/")
+	trimmed = strings.TrimPrefix(trimmed, "/")
+	var sb strings.Builder
+	if anchored {
+		sb.WriteString("^")
+	} else {
+		sb.WriteString("^(.*/)?")
+	}
+	for i := 0; i < len(trimmed); i++ {
+		c := trimmed[i]
+		switch {
+		case strings.HasPrefix(trimmed[i:], "**/"):
+			sb.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(trimmed[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	// "docs/*" only covers the files directly in docs, everything else covers what's beneath it.
+	if strings.HasSuffix(pattern, "/*") {
+		sb.WriteString("$")
+	} else {
+		sb.WriteString("(/.*)?$")
+	}
+	return regexp.Compile(sb.String())
+}
@@ -0,0 +1,73 @@
+package codeowners
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sample = `# default owners
+*                 @org/platform
+*.js              @org/frontend   # trailing comment
+/build/           @org/build
+docs/*            docs@example.com
+apps/             @org/apps
+/internal/**/db   @org/dba
+/vendor/
+`
+
+func TestOwners(t *testing.T) {
+	rs, err := Parse(strings.NewReader(sample))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{path: "main.go", want: []string{"@org/platform"}},
+		{path: "web/app.js", want: []string{"@org/frontend"}},
+		{path: "build/ci/run.sh", want: []string{"@org/build"}},
+		{path: "src/build/x.go", want: []string{"@org/platform"}},
+		{path: "docs/index.md", want: []string{"docs@example.com"}},
+		{path: "docs/api/index.md", want: []string{"@org/platform"}},
+		{path: "cmd/apps/main.go", want: []string{"@org/apps"}},
+		{path: "internal/db/conn.go", want: []string{"@org/dba"}},
+		{path: "internal/store/db/conn.go", want: []string{"@org/dba"}},
+		{path: "vendor/x/y.go", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got := rs.Owners(tt.path)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Owners(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	rs, err := Load(dir)
+	if err != nil || rs != nil {
+		t.Fatalf("Load without CODEOWNERS = %v, %v; want nil, nil", rs, err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "docs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "docs", "CODEOWNERS"), []byte("* @org/docs\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "CODEOWNERS"), []byte("* @org/root\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rs, err = Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := rs.Owners("a.go"); !reflect.DeepEqual(got, []string{"@org/root"}) {
+		t.Errorf("root CODEOWNERS should take precedence over docs/, got %v", got)
+	}
+}
@@ -0,0 +1,232 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/perbu/gogrok/reposync"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Repository is a repository as listed by a git hosting service.
+type Repository struct {
+	Name          string   `json:"name"`
+	CloneURL      string   `json:"clone_url"`
+	SSHURL        string   `json:"ssh_url"`
+	DefaultBranch string   `json:"default_branch"`
+	Language      string   `json:"language"`
+	Topics        []string `json:"topics"`
+	Archived      bool     `json:"archived"`
+	Fork          bool     `json:"fork"`
+}
+
+// Provider lists the repositories of an organisation on a git hosting service.
+type Provider interface {
+	Repositories(ctx context.Context, org string) ([]Repository, error)
+}
+
+// New returns the provider for a kind of hosting service, "github" or "gitea".
+func New(kind, baseURL, token string) (Provider, error) {
+	switch strings.ToLower(kind) {
+	case "github":
+		return &GitHub{BaseURL: baseURL, Token: token}, nil
+	case "gitea", "forgejo":
+		return &Gitea{BaseURL: baseURL, Token: token}, nil
+	default:
+		return nil, fmt.Errorf("unknown provider %q", kind)
+	}
+}
+
+// Filter selects the repositories that go into the manifest.
+type Filter struct {
+	Topics    []string // keep repositories with at least one of these topics, all if empty
+	Languages []string // keep repositories in one of these languages (case insensitive), all if empty
+	Archived  bool     // keep archived repositories
+	Forks     bool     // keep forks
+}
+
+// Match returns true if the filter keeps the repository.
+func (f Filter) Match(r Repository) bool {
+	if r.Archived && !f.Archived {
+		return false
+	}
+	if r.Fork && !f.Forks {
+		return false
+	}
+	if len(f.Languages) > 0 && !slices.ContainsFunc(f.Languages, func(l string) bool {
+		return strings.EqualFold(l, r.Language)
+	}) {
+		return false
+	}
+	if len(f.Topics) > 0 && !slices.ContainsFunc(f.Topics, func(t string) bool {
+		return slices.Contains(r.Topics, t)
+	}) {
+		return false
+	}
+	return true
+}
+
+// Manifest turns the repositories the filter keeps into a manifest for reposync, sorted by
+// name. With ssh the repositories are cloned over SSH, otherwise over HTTPS.
+func Manifest(repos []Repository, f Filter, ssh bool) *reposync.Manifest {
+	m := &reposync.Manifest{Repos: make([]reposync.Repo, 0, len(repos))}
+	for _, r := range repos {
+		if !f.Match(r) {
+			continue
+		}
+		url := r.CloneURL
+		if ssh {
+			url = r.SSHURL
+		}
+		m.Repos = append(m.Repos, reposync.Repo{URL: url, Branch: r.DefaultBranch, Dir: r.Name})
+	}
+	sort.Slice(m.Repos, func(i, j int) bool {
+		return m.Repos[i].Dir < m.Repos[j].Dir
+	})
+	return m
+}
+
+// GitHub lists repositories through the GitHub REST API.
+type GitHub struct {
+	BaseURL string // defaults to https://api.github.com, set it for GitHub Enterprise
+	Token   string // personal access token, needed for private repositories
+	Client  *http.Client
+}
+
+// Repositories lists the repositories of an organisation, or of a user if there is no
+// organisation by that name.
+func (g *GitHub) Repositories(ctx context.Context, org string) ([]Repository, error) {
+	base := strings.TrimRight(g.BaseURL, "/")
+	if base == "" {
+		base = "https://api.github.com"
+	}
+	header := http.Header{"Accept": {"application/vnd.github+json"}}
+	if g.Token != "" {
+		header.Set("Authorization", "Bearer "+g.Token)
+	}
+	repos, err := list(ctx, g.Client, base+"/orgs/"+url.PathEscape(org)+"/repos?type=all&per_page=100", header)
+	if isNotFound(err) {
+		repos, err = list(ctx, g.Client, base+"/users/"+url.PathEscape(org)+"/repos?type=all&per_page=100", header)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("github: %w", err)
+	}
+	return repos, nil
+}
+
+// Gitea lists repositories through the Gitea (or Forgejo) REST API.
+type Gitea struct {
+	BaseURL string // URL of the Gitea instance, like https://gitea.example.com
+	Token   string // access token, needed for private repositories
+	Client  *http.Client
+}
+
+// Repositories lists the repositories of an organisation, or of a user if there is no
+// organisation by that name.
+func (g *Gitea) Repositories(ctx context.Context, org string) ([]Repository, error) {
+	if g.BaseURL == "" {
+		return nil, fmt.Errorf("gitea: base URL missing")
+	}
+	base := strings.TrimRight(g.BaseURL, "/") + "/api/v1"
+	header := http.Header{"Accept": {"application/json"}}
+	if g.Token != "" {
+		header.Set("Authorization", "token "+g.Token)
+	}
+	repos, err := list(ctx, g.Client, base+"/orgs/"+url.PathEscape(org)+"/repos?limit=50", header)
+	if isNotFound(err) {
+		repos, err = list(ctx, g.Client, base+"/users/"+url.PathEscape(org)+"/repos?limit=50", header)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gitea: %w", err)
+	}
+	return repos, nil
+}
+
+// statusError is an API response other than 200 OK.
+type statusError struct {
+	URL    string
+	Status int
+	Body   string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("GET %s: %d %s: %s", e.URL, e.Status, http.StatusText(e.Status), e.Body)
+}
+
+func isNotFound(err error) bool {
+	var se *statusError
+	return errors.As(err, &se) && se.Status == http.StatusNotFound
+}
+
+// nextLink matches the next page in a Link header, both GitHub and Gitea paginate that way.
+var nextLink = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// list fetches all pages of a repository listing, following the Link headers. Next links
+// must point to the same scheme and host as the first page, the token is sent along.
+func list(ctx context.Context, client *http.Client, first string, header http.Header) ([]Repository, error) {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	base, err := url.Parse(first)
+	if err != nil {
+		return nil, fmt.Errorf("url.Parse: %w", err)
+	}
+	var repos []Repository
+	for next := base; next != nil; {
+		page, link, err := fetch(ctx, client, next.String(), header)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, page...)
+		next = nil
+		if link == "" || len(page) == 0 {
+			continue
+		}
+		next, err = base.Parse(link)
+		if err != nil {
+			return nil, fmt.Errorf("next page %q: %w", link, err)
+		}
+		if next.Scheme != base.Scheme || next.Host != base.Host {
+			return nil, fmt.Errorf("next page %s: not on %s://%s", next.Redacted(), base.Scheme, base.Host)
+		}
+	}
+	return repos, nil
+}
+
+// fetch gets one page of a repository listing and the next page from its Link header.
+func fetch(ctx context.Context, client *http.Client, url string, header http.Header) ([]Repository, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("http.NewRequest: %w", err)
+	}
+	req.Header = header.Clone()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("client.Do: %w", err)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, "", fmt.Errorf("reading %s: %w", url, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", &statusError{URL: url, Status: resp.StatusCode, Body: strings.TrimSpace(string(body[:min(len(body), 200)]))}
+	}
+	var page []Repository
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, "", fmt.Errorf("decoding %s: %w", url, err)
+	}
+	link := ""
+	if m := nextLink.FindStringSubmatch(resp.Header.Get("Link")); m != nil {
+		link = m[1]
+	}
+	return page, link, nil
+}
@@ -0,0 +1,170 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+var testRepos = []Repository{
+	{Name: "svc", CloneURL: "https://git.example.com/org/svc.git", SSHURL: "git@git.example.com:org/svc.git", DefaultBranch: "main", Language: "Go", Topics: []string{"backend"}},
+	{Name: "web", CloneURL: "https://git.example.com/org/web.git", SSHURL: "git@git.example.com:org/web.git", DefaultBranch: "main", Language: "TypeScript"},
+	{Name: "lib", CloneURL: "https://git.example.com/org/lib.git", SSHURL: "git@git.example.com:org/lib.git", DefaultBranch: "master", Language: "Go", Topics: []string{"library"}},
+	{Name: "old", CloneURL: "https://git.example.com/org/old.git", SSHURL: "git@git.example.com:org/old.git", DefaultBranch: "main", Language: "Go", Archived: true},
+	{Name: "fork", CloneURL: "https://git.example.com/org/fork.git", SSHURL: "git@git.example.com:org/fork.git", DefaultBranch: "main", Language: "Go", Fork: true},
+}
+
+// hostingServer is a stand-in for a hosting API listing testRepos for org, two per page.
+// Requests without the token are refused.
+func hostingServer(t *testing.T, orgPath, authorization string) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != authorization {
+			http.Error(w, `{"message": "Bad credentials"}`, http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != orgPath {
+			http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+			return
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		page = max(page, 1)
+		const perPage = 2
+		start := min((page-1)*perPage, len(testRepos))
+		end := min(start+perPage, len(testRepos))
+		if end < len(testRepos) {
+			next := fmt.Sprintf("%s%s?page=%d", srv.URL, r.URL.Path, page+1)
+			w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next", <%s>; rel="last"`, next, next))
+		}
+		if err := json.NewEncoder(w).Encode(testRepos[start:end]); err != nil {
+			t.Error(err)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGitHub(t *testing.T) {
+	srv := hostingServer(t, "/orgs/org/repos", "Bearer secret")
+	g := &GitHub{BaseURL: srv.URL, Token: "secret"}
+	repos, err := g.Repositories(context.Background(), "org")
+	if err != nil {
+		t.Fatalf("Repositories: %v", err)
+	}
+	if len(repos) != len(testRepos) {
+		t.Fatalf("got %d repositories, want %d", len(repos), len(testRepos))
+	}
+	if repos[2].Name != "lib" || repos[2].Topics[0] != "library" || repos[2].DefaultBranch != "master" {
+		t.Errorf("repository decoded as %+v", repos[2])
+	}
+
+	// users have no organisation, their repositories are listed elsewhere:
+	srv = hostingServer(t, "/users/someone/repos", "Bearer secret")
+	g = &GitHub{BaseURL: srv.URL, Token: "secret"}
+	if repos, err := g.Repositories(context.Background(), "someone"); err != nil || len(repos) != len(testRepos) {
+		t.Errorf("user repositories: got %d, %v", len(repos), err)
+	}
+
+	g.Token = "wrong"
+	if _, err := g.Repositories(context.Background(), "someone"); err == nil {
+		t.Errorf("bad credentials: no error")
+	}
+}
+
+func TestForeignNextLink(t *testing.T) {
+	var leaked bool
+	foreign := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked = leaked || r.Header.Get("Authorization") != ""
+		_, _ = w.Write([]byte("[]"))
+	}))
+	t.Cleanup(foreign.Close)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", fmt.Sprintf(`<%s/orgs/org/repos?page=2>; rel="next"`, foreign.URL))
+		_ = json.NewEncoder(w).Encode(testRepos[:2])
+	}))
+	t.Cleanup(srv.Close)
+
+	g := &GitHub{BaseURL: srv.URL, Token: "secret"}
+	if _, err := g.Repositories(context.Background(), "org"); err == nil {
+		t.Error("next link to another host: no error")
+	}
+	if leaked {
+		t.Error("the token was sent to another host")
+	}
+}
+
+func TestEscapedOrg(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		_, _ = w.Write([]byte("[]"))
+	}))
+	t.Cleanup(srv.Close)
+
+	g := &GitHub{BaseURL: srv.URL}
+	if _, err := g.Repositories(context.Background(), "../users/someone"); err != nil {
+		t.Fatalf("Repositories: %v", err)
+	}
+	if want := []string{"/orgs/..%2Fusers%2Fsomeone/repos"}; !slices.Equal(paths, want) {
+		t.Errorf("requested %v, want %v", paths, want)
+	}
+}
+
+func TestGitea(t *testing.T) {
+	srv := hostingServer(t, "/api/v1/orgs/org/repos", "token secret")
+	p, err := New("gitea", srv.URL+"/", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repos, err := p.Repositories(context.Background(), "org")
+	if err != nil {
+		t.Fatalf("Repositories: %v", err)
+	}
+	if len(repos) != len(testRepos) {
+		t.Errorf("got %d repositories, want %d", len(repos), len(testRepos))
+	}
+	if _, err := (&Gitea{}).Repositories(context.Background(), "org"); err == nil {
+		t.Errorf("no base URL: no error")
+	}
+}
+
+func TestManifest(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		ssh    bool
+		want   []string
+	}{
+		{name: "default", filter: Filter{}, want: []string{"lib", "svc", "web"}},
+		{name: "language", filter: Filter{Languages: []string{"go"}}, want: []string{"lib", "svc"}},
+		{name: "topic", filter: Filter{Topics: []string{"library", "tools"}}, want: []string{"lib"}},
+		{name: "archived and forks", filter: Filter{Languages: []string{"Go"}, Archived: true, Forks: true}, want: []string{"fork", "lib", "old", "svc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Manifest(testRepos, tt.filter, tt.ssh)
+			var got []string
+			for _, r := range m.Repos {
+				got = append(got, r.Dir)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	m := Manifest(testRepos[:1], Filter{}, true)
+	if r := m.Repos[0]; r.URL != "git@git.example.com:org/svc.git" || r.Branch != "main" {
+		t.Errorf("ssh manifest entry %+v", r)
+	}
+	m = Manifest(testRepos[:1], Filter{}, false)
+	if r := m.Repos[0]; r.URL != "https://git.example.com/org/svc.git" {
+		t.Errorf("https manifest entry %+v", r)
+	}
+}
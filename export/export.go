@@ -0,0 +1,318 @@
+// Package export writes dependency graphs in formats other tools read: Graphviz DOT, Mermaid,
+// GraphML and a JSON node-link format.
+package export
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"github.com/perbu/gogrok/analytics"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Format is a graph file format.
+type Format string
+
+const (
+	DOT     Format = "dot"
+	Mermaid Format = "mermaid"
+	GraphML Format = "graphml"
+	JSON    Format = "json"
+)
+
+// Formats are the supported formats.
+var Formats = []Format{DOT, Mermaid, GraphML, JSON}
+
+// ParseFormat parses the name of a format.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown format %q, want dot, mermaid, graphml or json", s)
+}
+
+// Extension returns the file name extension of the format.
+func (f Format) Extension() string {
+	switch f {
+	case Mermaid:
+		return "mmd"
+	default:
+		return string(f)
+	}
+}
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case DOT:
+		return "text/vnd.graphviz"
+	case GraphML:
+		return "application/graphml+xml"
+	case JSON:
+		return "application/json"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+// Options pick the part of the dependency graph to export.
+type Options struct {
+	Packages  bool   // the package import graph rather than the module graph
+	Prefix    string // start from the local modules whose path starts with this, all if empty
+	LocalOnly bool   // leave out external modules and packages
+	Depth     int    // how many edges away from the local modules to go, 0 for no limit
+}
+
+// Graph cuts the graph given by the options out of the analysis.
+func Graph(r *analytics.Repo, o Options) *analytics.Graph {
+	opts := analytics.GraphOptions{Depth: o.Depth, LocalOnly: o.LocalOnly}
+	if o.Packages {
+		return r.LocalPackageGraph(o.Prefix, opts)
+	}
+	return r.LocalModuleGraph(o.Prefix, opts)
+}
+
+// Filename returns a name to save the graph given by the options as.
+func Filename(o Options, f Format) string {
+	if o.Packages {
+		return "gogrok-packages." + f.Extension()
+	}
+	return "gogrok-modules." + f.Extension()
+}
+
+// Write writes the graph in the format. Packages are grouped by module where the format
+// supports it.
+func Write(w io.Writer, g *analytics.Graph, f Format) error {
+	switch f {
+	case DOT:
+		return writeDOT(w, g)
+	case Mermaid:
+		return writeMermaid(w, g)
+	case GraphML:
+		return writeGraphML(w, g)
+	case JSON:
+		return writeJSON(w, g)
+	default:
+		return fmt.Errorf("export.Write: unknown format %q", f)
+	}
+}
+
+// clusters groups the local packages of a package graph by module, in order of the module path.
+// The other nodes are returned on their own.
+func clusters(g *analytics.Graph) (modules []string, members map[string][]analytics.GraphNode, rest []analytics.GraphNode) {
+	members = make(map[string][]analytics.GraphNode)
+	for _, n := range g.Nodes {
+		if n.Package == "" {
+			rest = append(rest, n)
+			continue
+		}
+		if _, ok := members[n.Module]; !ok {
+			modules = append(modules, n.Module)
+		}
+		members[n.Module] = append(members[n.Module], n)
+	}
+	sort.Strings(modules)
+	return modules, members, rest
+}
+
+func writeDOT(w io.Writer, g *analytics.Graph) error {
+	var b strings.Builder
+	b.WriteString("digraph gogrok {\n\trankdir=LR;\n\tnode [shape=box, style=filled, fillcolor=white];\n")
+	node := func(indent string, n analytics.GraphNode) {
+		attrs := "label=" + dotQuote(n.Label)
+		if n.Local {
+			attrs += ", fillcolor=lightblue"
+		}
+		if n.ID == g.Root {
+			attrs += ", penwidth=2"
+		}
+		fmt.Fprintf(&b, "%s%s [%s];\n", indent, dotQuote(n.ID), attrs)
+	}
+	modules, members, rest := clusters(g)
+	for i, m := range modules {
+		fmt.Fprintf(&b, "\tsubgraph cluster_%d {\n\t\tlabel=%s;\n", i, dotQuote(m))
+		for _, n := range members[m] {
+			node("\t\t", n)
+		}
+		b.WriteString("\t}\n")
+	}
+	for _, n := range rest {
+		node("\t", n)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "\t%s -> %s;\n", dotQuote(e.From), dotQuote(e.To))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dotQuote quotes an ID or label for DOT, escaping backslashes and double quotes.
+func dotQuote(s string) string {
+	return `"` + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), `"`, `\"`) + `"`
+}
+
+func writeMermaid(w io.Writer, g *analytics.Graph) error {
+	// Mermaid IDs can't contain the slashes and dots of import paths, so nodes are numbered:
+	ids := make(map[string]string, len(g.Nodes))
+	for i, n := range g.Nodes {
+		ids[n.ID] = "n" + strconv.Itoa(i)
+	}
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	node := func(indent string, n analytics.GraphNode) {
+		fmt.Fprintf(&b, "%s%s[%s]\n", indent, ids[n.ID], mermaidQuote(n.Label))
+	}
+	modules, members, rest := clusters(g)
+	for i, m := range modules {
+		fmt.Fprintf(&b, "\tsubgraph m%d[%s]\n", i, mermaidQuote(m))
+		for _, n := range members[m] {
+			node("\t\t", n)
+		}
+		b.WriteString("\tend\n")
+	}
+	for _, n := range rest {
+		node("\t", n)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "\t%s --> %s\n", ids[e.From], ids[e.To])
+	}
+	var local []string
+	for _, n := range g.Nodes {
+		if n.Local {
+			local = append(local, ids[n.ID])
+		}
+	}
+	if len(local) > 0 {
+		fmt.Fprintf(&b, "\tclassDef local fill:#cfe2ff\n\tclass %s local\n", strings.Join(local, ","))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mermaidQuote quotes a label for Mermaid, which has entity codes instead of escapes.
+func mermaidQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "#quot;") + `"`
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   struct {
+		ID          string        `xml:"id,attr"`
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphMLNode `xml:"node"`
+		Edges       []graphMLEdge `xml:"edge"`
+	} `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+func writeGraphML(w io.Writer, g *analytics.Graph) error {
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "label", For: "node", Name: "label", Type: "string"},
+			{ID: "module", For: "node", Name: "module", Type: "string"},
+			{ID: "package", For: "node", Name: "package", Type: "string"},
+			{ID: "local", For: "node", Name: "local", Type: "boolean"},
+			{ID: "depth", For: "node", Name: "depth", Type: "int"},
+			{ID: "license", For: "node", Name: "license", Type: "string"},
+		},
+	}
+	doc.Graph.ID = "gogrok"
+	doc.Graph.EdgeDefault = "directed"
+	for _, n := range g.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{ID: n.ID, Data: []graphMLData{
+			{Key: "label", Value: n.Label},
+			{Key: "module", Value: n.Module},
+			{Key: "package", Value: n.Package},
+			{Key: "local", Value: strconv.FormatBool(n.Local)},
+			{Key: "depth", Value: strconv.Itoa(n.Depth)},
+			{Key: "license", Value: n.License},
+		}})
+	}
+	for _, e := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{Source: e.From, Target: e.To})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("xml Encode: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// nodeLink is the node-link format of JSON graphs, as read by networkx and d3.
+type nodeLink struct {
+	Directed bool       `json:"directed"`
+	Root     string     `json:"root,omitempty"`
+	Nodes    []jsonNode `json:"nodes"`
+	Links    []jsonLink `json:"links"`
+}
+
+type jsonNode struct {
+	ID      string `json:"id"`
+	Label   string `json:"label"`
+	Module  string `json:"module"`
+	Package string `json:"package,omitempty"`
+	Local   bool   `json:"local"`
+	Depth   int    `json:"depth"`
+	License string `json:"license,omitempty"`
+}
+
+type jsonLink struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+func writeJSON(w io.Writer, g *analytics.Graph) error {
+	doc := nodeLink{
+		Directed: true,
+		Root:     g.Root,
+		Nodes:    make([]jsonNode, 0, len(g.Nodes)),
+		Links:    make([]jsonLink, 0, len(g.Edges)),
+	}
+	for _, n := range g.Nodes {
+		doc.Nodes = append(doc.Nodes, jsonNode(n))
+	}
+	for _, e := range g.Edges {
+		doc.Links = append(doc.Links, jsonLink{Source: e.From, Target: e.To})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("json Encode: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"github.com/perbu/gogrok/analytics"
+	"strings"
+	"testing"
+)
+
+// packageGraph is svc#main importing lib#util, which imports an external package.
+var packageGraph = &analytics.Graph{
+	Root: "example.com/svc#main",
+	Nodes: []analytics.GraphNode{
+		{ID: "example.com/lib#util", Label: "util", Module: "example.com/lib", Package: "util", Local: true, Depth: 1},
+		{ID: "example.com/svc#main", Label: "main", Module: "example.com/svc", Package: "main", Local: true},
+		{ID: "golang.org/x/mod/semver", Label: `golang.org/x/mod/"semver"`, Module: "golang.org/x/mod", Depth: 2, License: "BSD-3-Clause"},
+	},
+	Edges: []analytics.GraphEdge{
+		{From: "example.com/lib#util", To: "golang.org/x/mod/semver"},
+		{From: "example.com/svc#main", To: "example.com/lib#util"},
+	},
+}
+
+func TestDOT(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, packageGraph, DOT); err != nil {
+		t.Fatal(err)
+	}
+	want := `digraph gogrok {
+	rankdir=LR;
+	node [shape=box, style=filled, fillcolor=white];
+	subgraph cluster_0 {
+		label="example.com/lib";
+		"example.com/lib#util" [label="util", fillcolor=lightblue];
+	}
+	subgraph cluster_1 {
+		label="example.com/svc";
+		"example.com/svc#main" [label="main", fillcolor=lightblue, penwidth=2];
+	}
+	"golang.org/x/mod/semver" [label="golang.org/x/mod/\"semver\""];
+	"example.com/lib#util" -> "golang.org/x/mod/semver";
+	"example.com/svc#main" -> "example.com/lib#util";
+}
+`
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestMermaid(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, packageGraph, Mermaid); err != nil {
+		t.Fatal(err)
+	}
+	want := `graph LR
+	subgraph m0["example.com/lib"]
+		n0["util"]
+	end
+	subgraph m1["example.com/svc"]
+		n1["main"]
+	end
+	n2["golang.org/x/mod/#quot;semver#quot;"]
+	n0 --> n2
+	n1 --> n0
+	classDef local fill:#cfe2ff
+	class n0,n1 local
+`
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestGraphML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, packageGraph, GraphML); err != nil {
+		t.Fatal(err)
+	}
+	var doc graphML
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("xml.Unmarshal: %v\n%s", err, buf.String())
+	}
+	if len(doc.Graph.Nodes) != 3 || len(doc.Graph.Edges) != 2 || doc.Graph.EdgeDefault != "directed" {
+		t.Fatalf("graph: %+v", doc.Graph)
+	}
+	if n := doc.Graph.Nodes[0]; n.ID != "example.com/lib#util" || n.Data[2] != (graphMLData{Key: "package", Value: "util"}) {
+		t.Errorf("node: %+v", n)
+	}
+	if n := doc.Graph.Nodes[2]; n.Data[5] != (graphMLData{Key: "license", Value: "BSD-3-Clause"}) {
+		t.Errorf("node: %+v", n)
+	}
+	if !strings.HasPrefix(buf.String(), "<?xml") {
+		t.Errorf("no XML header")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, packageGraph, JSON); err != nil {
+		t.Fatal(err)
+	}
+	var doc nodeLink
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if !doc.Directed || doc.Root != "example.com/svc#main" || len(doc.Nodes) != 3 || doc.Links[1] != (jsonLink{Source: "example.com/svc#main", Target: "example.com/lib#util"}) {
+		t.Errorf("got %+v", doc)
+	}
+	if doc.Nodes[0].License != "" || doc.Nodes[2].License != "BSD-3-Clause" {
+		t.Errorf("licenses: %+v", doc.Nodes)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, f := range Formats {
+		got, err := ParseFormat(string(f))
+		if err != nil || got != f {
+			t.Errorf("ParseFormat(%q) = %q, %v", f, got, err)
+		}
+	}
+	if _, err := ParseFormat("png"); err == nil {
+		t.Errorf("ParseFormat(png): no error")
+	}
+}
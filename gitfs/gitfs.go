@@ -0,0 +1,221 @@
+package gitfs
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// FS is a read-only view of a git tree at a given revision, read straight from the object
+// store without touching the working tree. It implements fs.FS, fs.ReadDirFS and fs.ReadFileFS.
+type FS struct {
+	Commit string // hash of the commit the tree belongs to
+	tree   *object.Tree
+	when   time.Time
+}
+
+// Open resolves ref (a tag, branch, commit hash or any other git revision) in the git repository
+// holding dir and returns the tree of dir at that revision.
+func Open(dir, ref string) (*FS, error) {
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("git.PlainOpen: %w", err)
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return nil, fmt.Errorf("resolve %q: %w", ref, err)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("repo.CommitObject: %w", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("commit.Tree: %w", err)
+	}
+	// the directory might be a subdirectory of the worktree:
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("repo.Worktree: %w", err)
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("filepath.Abs: %w", err)
+	}
+	rel, err := filepath.Rel(wt.Filesystem.Root(), abs)
+	if err != nil {
+		return nil, fmt.Errorf("filepath.Rel: %w", err)
+	}
+	if rel != "." {
+		tree, err = tree.Tree(filepath.ToSlash(rel))
+		if err != nil {
+			return nil, fmt.Errorf("tree.Tree(%s): %w", rel, err)
+		}
+	}
+	return &FS{Commit: hash.String(), tree: tree, when: commit.Committer.When}, nil
+}
+
+// Open opens the named file or directory.
+func (f *FS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return &file{info: f.dirInfo("."), fs: f, path: name}, nil
+	}
+	entry, err := f.tree.FindEntry(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if entry.Mode == filemode.Dir {
+		return &file{info: f.dirInfo(path.Base(name)), fs: f, path: name}, nil
+	}
+	blob, err := f.tree.File(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	r, err := blob.Reader()
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &file{info: f.fileInfo(path.Base(name), blob.Size, entry.Mode), r: r}, nil
+}
+
+// ReadFile returns the contents of the named file.
+func (f *FS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+	blob, err := f.tree.File(name)
+	if errors.Is(err, object.ErrFileNotFound) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+	contents, err := blob.Contents()
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+	return []byte(contents), nil
+}
+
+// ReadDir returns the entries of the named directory.
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	tree := f.tree
+	if name != "." {
+		var err error
+		tree, err = f.tree.Tree(name)
+		if err != nil {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+		}
+	}
+	entries := make([]fs.DirEntry, 0, len(tree.Entries))
+	for _, e := range tree.Entries {
+		switch e.Mode {
+		case filemode.Dir:
+			entries = append(entries, f.dirInfo(e.Name))
+		case filemode.Submodule:
+			// submodules aren't part of the tree
+		default:
+			size, err := tree.Size(e.Name)
+			if err != nil {
+				return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+			}
+			entries = append(entries, f.fileInfo(e.Name, size, e.Mode))
+		}
+	}
+	return entries, nil
+}
+
+func (f *FS) dirInfo(name string) *fileInfo {
+	return &fileInfo{name: name, mode: fs.ModeDir | 0o555, modTime: f.when}
+}
+
+func (f *FS) fileInfo(name string, size int64, mode filemode.FileMode) *fileInfo {
+	fm, err := mode.ToOSFileMode()
+	if err != nil {
+		fm = 0o444
+	}
+	return &fileInfo{name: name, size: size, mode: fm, modTime: f.when}
+}
+
+// fileInfo implements both fs.FileInfo and fs.DirEntry.
+type fileInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (i *fileInfo) Name() string               { return i.name }
+func (i *fileInfo) Size() int64                { return i.size }
+func (i *fileInfo) Mode() fs.FileMode          { return i.mode }
+func (i *fileInfo) ModTime() time.Time         { return i.modTime }
+func (i *fileInfo) IsDir() bool                { return i.mode.IsDir() }
+func (i *fileInfo) Sys() any                   { return nil }
+func (i *fileInfo) Type() fs.FileMode          { return i.mode.Type() }
+func (i *fileInfo) Info() (fs.FileInfo, error) { return i, nil }
+
+type file struct {
+	info *fileInfo
+	r    io.ReadCloser // nil for directories
+	// for directories:
+	fs      *FS
+	path    string
+	entries []fs.DirEntry
+	read    bool
+}
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.info, nil }
+
+func (f *file) Read(p []byte) (int, error) {
+	if f.r == nil {
+		return 0, &fs.PathError{Op: "read", Path: f.info.name, Err: errors.New("is a directory")}
+	}
+	return f.r.Read(p)
+}
+
+func (f *file) Close() error {
+	if f.r == nil {
+		return nil
+	}
+	return f.r.Close()
+}
+
+// ReadDir implements fs.ReadDirFile for directories.
+func (f *file) ReadDir(n int) ([]fs.DirEntry, error) {
+	if f.fs == nil {
+		return nil, &fs.PathError{Op: "readdir", Path: f.info.name, Err: errors.New("not a directory")}
+	}
+	if !f.read {
+		entries, err := f.fs.ReadDir(f.path)
+		if err != nil {
+			return nil, err
+		}
+		f.entries, f.read = entries, true
+	}
+	if n <= 0 {
+		entries := f.entries
+		f.entries = nil
+		return entries, nil
+	}
+	if len(f.entries) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(f.entries))
+	entries := f.entries[:n]
+	f.entries = f.entries[n:]
+	return entries, nil
+}
@@ -0,0 +1,72 @@
+package gitfs
+
+import (
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func commitFiles(t *testing.T, repo *git.Repository, dir string, files map[string]string) {
+	t.Helper()
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := wt.Add(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sig := &object.Signature{Name: "dev", Email: "dev@example.com", When: time.Now()}
+	if _, err := wt.Commit("commit", &git.CommitOptions{Author: sig}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFS(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitFiles(t, repo, dir, map[string]string{
+		"mod/go.mod":      "module example.com/mod\n",
+		"mod/pkg/a.go":    "package pkg\n",
+		"other/readme.md": "other\n",
+	})
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CreateTag("v1.0.0", head.Hash(), nil); err != nil {
+		t.Fatal(err)
+	}
+	// change the working tree and history after the tag:
+	commitFiles(t, repo, dir, map[string]string{"mod/pkg/b.go": "package pkg\n"})
+
+	fsys, err := Open(filepath.Join(dir, "mod"), "v1.0.0")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if fsys.Commit != head.Hash().String() {
+		t.Errorf("Commit = %s, want %s", fsys.Commit, head.Hash())
+	}
+	if err := fstest.TestFS(fsys, "go.mod", "pkg/a.go"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.Stat(fsys, "pkg/b.go"); err == nil {
+		t.Error("pkg/b.go was added after v1.0.0 and should not exist")
+	}
+}
@@ -0,0 +1,143 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Ownership is the number of lines per author, keyed by email, in the current version of one
+// or more files.
+type Ownership map[string]int
+
+// Owner is an author with the lines they own.
+type Owner struct {
+	Email string
+	Lines int
+	Share float32 // share of all lines, 0-1
+}
+
+// Merge adds the lines of another file.
+func (o Ownership) Merge(other Ownership) {
+	for email, lines := range other {
+		o[email] += lines
+	}
+}
+
+// Total returns the number of lines.
+func (o Ownership) Total() int {
+	total := 0
+	for _, lines := range o {
+		total += lines
+	}
+	return total
+}
+
+// Owners returns the authors sorted by the number of lines they own, largest first.
+func (o Ownership) Owners() []Owner {
+	total := o.Total()
+	owners := make([]Owner, 0, len(o))
+	for email, lines := range o {
+		owners = append(owners, Owner{Email: email, Lines: lines, Share: float32(lines) / float32(total)})
+	}
+	sort.Slice(owners, func(i, j int) bool {
+		if owners[i].Lines != owners[j].Lines {
+			return owners[i].Lines > owners[j].Lines
+		}
+		return owners[i].Email < owners[j].Email
+	})
+	return owners
+}
+
+// MajorityOwners returns the smallest set of authors that together own more than half of the lines.
+func (o Ownership) MajorityOwners() []Owner {
+	owners := o.Owners()
+	total := o.Total()
+	covered := 0
+	for i, owner := range owners {
+		covered += owner.Lines
+		if covered*2 > total {
+			return owners[:i+1]
+		}
+	}
+	return owners
+}
+
+// BusFactor returns the minimum number of authors owning more than half of the lines, 0 if
+// there are no lines at all.
+func (o Ownership) BusFactor() int {
+	return len(o.MajorityOwners())
+}
+
+// Blame attributes every line of the given files at HEAD to the author who last changed it.
+// The files are given as slash separated paths relative to dir, the git repository holding
+// dir is used. Files that aren't committed are left out.
+func Blame(dir string, files []string) (map[string]Ownership, error) {
+	repo, prefix, err := open(dir)
+	if err != nil {
+		return nil, err
+	}
+	head, err := headCommit(repo)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]Ownership, len(files))
+	for _, name := range files {
+		_, err := head.File(prefix + name)
+		if errors.Is(err, object.ErrFileNotFound) {
+			continue
+		}
+		blame, err := git.Blame(head, prefix+name)
+		if err != nil {
+			return nil, fmt.Errorf("git.Blame(%s): %w", name, err)
+		}
+		own := make(Ownership)
+		for _, line := range blame.Lines {
+			own[strings.ToLower(line.Author)]++
+		}
+		result[name] = own
+	}
+	return result, nil
+}
+
+// LastCommits returns the time of the most recent commit on HEAD for every author in the
+// repository holding dir.
+func LastCommits(dir string) (map[string]time.Time, error) {
+	repo, _, err := open(dir)
+	if err != nil {
+		return nil, err
+	}
+	iter, err := repo.Log(&git.LogOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("repo.Log: %w", err)
+	}
+	defer iter.Close()
+	last := make(map[string]time.Time)
+	err = iter.ForEach(func(c *object.Commit) error {
+		email := strings.ToLower(c.Author.Email)
+		if c.Author.When.After(last[email]) {
+			last[email] = c.Author.When
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("iter.ForEach: %w", err)
+	}
+	return last, nil
+}
+
+func headCommit(repo *git.Repository) (*object.Commit, error) {
+	ref, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("repo.Head: %w", err)
+	}
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("repo.CommitObject: %w", err)
+	}
+	return commit, nil
+}
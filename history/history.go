@@ -0,0 +1,193 @@
+package history
+
+import (
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Stats is the change history of a file, or the aggregate of several files.
+type Stats struct {
+	Added       int // lines added
+	Removed     int // lines removed
+	FirstCommit time.Time
+	LastCommit  time.Time
+	commits     map[string]string // author email by hash of the commits touching the file(s)
+}
+
+// Author is an author with the number of commits they made.
+type Author struct {
+	Email   string
+	Commits int
+}
+
+// NewStats returns an empty history.
+func NewStats() *Stats {
+	return &Stats{
+		commits: make(map[string]string),
+	}
+}
+
+// Commits returns the number of distinct commits.
+func (s *Stats) Commits() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.commits)
+}
+
+// Churn returns the number of lines added and removed.
+func (s *Stats) Churn() int {
+	if s == nil {
+		return 0
+	}
+	return s.Added + s.Removed
+}
+
+// Authors returns the number of commits per author.
+func (s *Stats) Authors() map[string]int {
+	authors := make(map[string]int)
+	if s == nil {
+		return authors
+	}
+	for _, email := range s.commits {
+		authors[email]++
+	}
+	return authors
+}
+
+// TopAuthors returns the authors sorted by number of commits, most active first.
+func (s *Stats) TopAuthors() []Author {
+	counts := s.Authors()
+	authors := make([]Author, 0, len(counts))
+	for email, commits := range counts {
+		authors = append(authors, Author{Email: email, Commits: commits})
+	}
+	sort.Slice(authors, func(i, j int) bool {
+		if authors[i].Commits != authors[j].Commits {
+			return authors[i].Commits > authors[j].Commits
+		}
+		return authors[i].Email < authors[j].Email
+	})
+	return authors
+}
+
+// ChangedWithin tells if there was a commit within the last d.
+func (s *Stats) ChangedWithin(d time.Duration) bool {
+	return s != nil && !s.LastCommit.IsZero() && time.Since(s.LastCommit) < d
+}
+
+// Merge adds the history of another file. Commits touching both are only counted once.
+func (s *Stats) Merge(o *Stats) {
+	if o == nil {
+		return
+	}
+	s.Added += o.Added
+	s.Removed += o.Removed
+	for hash, email := range o.commits {
+		s.commits[hash] = email
+	}
+	s.see(o.FirstCommit)
+	s.see(o.LastCommit)
+}
+
+func (s *Stats) see(when time.Time) {
+	if when.IsZero() {
+		return
+	}
+	if s.FirstCommit.IsZero() || when.Before(s.FirstCommit) {
+		s.FirstCommit = when
+	}
+	if when.After(s.LastCommit) {
+		s.LastCommit = when
+	}
+}
+
+// Add records a commit that added and removed the given number of lines.
+func (s *Stats) Add(hash, email string, when time.Time, added, removed int) {
+	s.commits[hash] = strings.ToLower(email)
+	s.Added += added
+	s.Removed += removed
+	s.see(when)
+}
+
+// Log walks the history of HEAD in the git repository holding dir, going back to since,
+// and returns the change history per file. The files are keyed by their slash separated
+// path relative to dir, files outside dir are left out. Merge commits are skipped, their
+// changes are already accounted for by the commits being merged.
+func Log(dir string, since time.Time) (map[string]*Stats, error) {
+	repo, prefix, err := open(dir)
+	if err != nil {
+		return nil, err
+	}
+	iter, err := repo.Log(&git.LogOptions{Since: &since, Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return nil, fmt.Errorf("repo.Log: %w", err)
+	}
+	defer iter.Close()
+	files := make(map[string]*Stats)
+	err = iter.ForEach(func(c *object.Commit) error {
+		if c.NumParents() > 1 {
+			return nil
+		}
+		fileStats, err := c.Stats()
+		if err != nil {
+			return fmt.Errorf("commit %s: %w", c.Hash, err)
+		}
+		for _, fs := range fileStats {
+			name, ok := strings.CutPrefix(fs.Name, prefix)
+			if !ok {
+				continue
+			}
+			st, ok := files[name]
+			if !ok {
+				st = NewStats()
+				files[name] = st
+			}
+			st.Add(c.Hash.String(), c.Author.Email, c.Author.When, fs.Addition, fs.Deletion)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("iter.ForEach: %w", err)
+	}
+	return files, nil
+}
+
+// Aggregate merges the history of all files.
+func Aggregate(files map[string]*Stats) *Stats {
+	total := NewStats()
+	for _, st := range files {
+		total.Merge(st)
+	}
+	return total
+}
+
+// open opens the git repository holding dir and returns the path of dir within the
+// worktree, as a prefix ending with a slash, or empty if dir is the worktree root.
+func open(dir string) (*git.Repository, string, error) {
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, "", fmt.Errorf("git.PlainOpen: %w", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, "", fmt.Errorf("repo.Worktree: %w", err)
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, "", fmt.Errorf("filepath.Abs: %w", err)
+	}
+	rel, err := filepath.Rel(wt.Filesystem.Root(), abs)
+	if err != nil {
+		return nil, "", fmt.Errorf("filepath.Rel: %w", err)
+	}
+	if rel == "." {
+		return repo, "", nil
+	}
+	return repo, filepath.ToSlash(rel) + "/", nil
+}
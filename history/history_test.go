@@ -0,0 +1,104 @@
+package history
+
+import (
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// commitFile writes a file into the worktree and commits it as the given author.
+func commitFile(t *testing.T, repo *git.Repository, dir, name, content, email string, when time.Time) {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.Add(name); err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: email, Email: email, When: when}
+	if _, err := wt.Commit("change "+name, &git.CommitOptions{Author: sig, Committer: sig}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLog(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	commitFile(t, repo, dir, "mod/a.go", "package a\n", "old@example.com", now.Add(-48*time.Hour))
+	commitFile(t, repo, dir, "mod/a.go", "package a\n\nfunc A() {}\n", "Alice@example.com", now.Add(-24*time.Hour))
+	commitFile(t, repo, dir, "mod/b.go", "package a\n\nfunc B() {}\n", "alice@example.com", now.Add(-time.Hour))
+	commitFile(t, repo, dir, "other.go", "package other\n", "bob@example.com", now)
+
+	files, err := Log(filepath.Join(dir, "mod"), now.Add(-36*time.Hour))
+	if err != nil {
+		t.Fatalf("Log: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Log returned %d files, want 2 (other.go is outside the module): %v", len(files), files)
+	}
+	a := files["a.go"]
+	if a.Commits() != 1 || a.Added != 2 || a.Removed != 0 {
+		t.Errorf("a.go: commits %d, +%d -%d; want 1, +2 -0", a.Commits(), a.Added, a.Removed)
+	}
+	total := Aggregate(files)
+	if total.Commits() != 2 {
+		t.Errorf("Aggregate commits = %d, want 2", total.Commits())
+	}
+	authors := total.TopAuthors()
+	if len(authors) != 1 || authors[0].Email != "alice@example.com" || authors[0].Commits != 2 {
+		t.Errorf("TopAuthors() = %v, want alice with 2 commits", authors)
+	}
+	if !total.ChangedWithin(2 * time.Hour) {
+		t.Errorf("ChangedWithin(2h) = false, last commit %v", total.LastCommit)
+	}
+}
+
+func TestBlame(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	commitFile(t, repo, dir, "a.go", "package a\n\nfunc A() {}\n", "alice@example.com", now.Add(-400*24*time.Hour))
+	commitFile(t, repo, dir, "a.go", "package a\n\nfunc A() {}\n\nfunc B() {}\n", "bob@example.com", now)
+
+	owners, err := Blame(dir, []string{"a.go"})
+	if err != nil {
+		t.Fatalf("Blame: %v", err)
+	}
+	own := owners["a.go"]
+	if own["alice@example.com"] != 3 || own["bob@example.com"] != 2 {
+		t.Errorf("ownership = %v, want alice 3 lines, bob 2 lines", own)
+	}
+	if got := own.BusFactor(); got != 1 {
+		t.Errorf("BusFactor() = %d, want 1", got)
+	}
+	own.Merge(Ownership{"carol@example.com": 2})
+	if got := own.BusFactor(); got != 2 {
+		t.Errorf("BusFactor() after merge = %d, want 2", got)
+	}
+
+	last, err := LastCommits(dir)
+	if err != nil {
+		t.Fatalf("LastCommits: %v", err)
+	}
+	if time.Since(last["alice@example.com"]) < 365*24*time.Hour {
+		t.Errorf("alice last commit = %v, want more than a year ago", last["alice@example.com"])
+	}
+}
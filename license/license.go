@@ -0,0 +1,174 @@
+// Package license finds the license files of a module and recognizes the licenses in them by
+// their SPDX identifiers.
+package license
+
+import (
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Info is what was found out about the license of a module.
+type Info struct {
+	Files []string // license files in the root of the module
+	IDs   []string // SPDX identifiers or expressions of the licenses recognized in them, sorted
+}
+
+// Found tells if the module has license files, whether they were recognized or not.
+func (i Info) Found() bool {
+	return len(i.Files) > 0
+}
+
+// Expression returns the licenses as an SPDX license expression. The licenses of several files
+// all apply, so they are combined with AND; a dual licensed module says so with an OR in an
+// SPDX-License-Identifier. It returns the empty string if none were recognized.
+func (i Info) Expression() string {
+	terms := make([]string, len(i.IDs))
+	for n, id := range i.IDs {
+		if strings.Contains(id, " ") && len(i.IDs) > 1 {
+			id = "(" + id + ")"
+		}
+		terms[n] = id
+	}
+	return strings.Join(terms, " AND ")
+}
+
+// Detect reads the license files in the root of fsys, LICENSE, COPYING and the like, with or
+// without an extension.
+func Detect(fsys fs.FS) (Info, error) {
+	var info Info
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return info, fmt.Errorf("fs.ReadDir: %w", err)
+	}
+	ids := make(map[string]bool)
+	for _, e := range entries {
+		if e.IsDir() || !isLicenseFile(e.Name()) {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return info, fmt.Errorf("fs.ReadFile: %w", err)
+		}
+		info.Files = append(info.Files, e.Name())
+		if id, ok := Identify(string(data)); ok {
+			ids[id] = true
+		}
+	}
+	for id := range ids {
+		info.IDs = append(info.IDs, id)
+	}
+	sort.Strings(info.IDs)
+	return info, nil
+}
+
+// isLicenseFile tells if a file name is that of a license: LICENSE, LICENCE, COPYING, UNLICENSE,
+// optionally with an extension (LICENSE.md) or a suffix (LICENSE-MIT).
+func isLicenseFile(name string) bool {
+	base := strings.ToUpper(name)
+	for _, prefix := range []string{"LICENSE", "LICENCE", "COPYING", "UNLICENSE"} {
+		if rest, ok := strings.CutPrefix(base, prefix); ok && (rest == "" || rest[0] == '.' || rest[0] == '-' || rest[0] == '_') {
+			return true
+		}
+	}
+	return false
+}
+
+// license is recognized when its text has all of its phrases.
+type license struct {
+	id      string
+	phrases []string // normalized, see normalize
+}
+
+// licenses are tried in order, so a license comes before any license whose phrases its text
+// has: the MPL lists the GPLs as secondary licenses, the GPL mentions the AGPL and the LGPL,
+// BSD-3-Clause has all of BSD-2-Clause. The phrases of the GPL family are the titles with their
+// dates, and what sets the variants apart. A text doesn't say whether later versions apply, that
+// is up to the notices in the source, so the GPL family is identified as -only.
+var licenses = []license{
+	{"MPL-2.0", []string{"mozilla public license version 2 0"}},
+	{"AGPL-3.0-only", []string{"gnu affero general public license version 3 19 november 2007"}},
+	{"AGPL-3.0-only", []string{"gnu affero general public license", "remote network interaction"}},
+	{"LGPL-3.0-only", []string{"gnu lesser general public license version 3 29 june 2007"}},
+	{"LGPL-2.1-only", []string{"gnu lesser general public license version 2 1 february 1999"}},
+	{"LGPL-2.0-only", []string{"gnu library general public license version 2 june 1991"}},
+	{"GPL-3.0-only", []string{"gnu general public license version 3 29 june 2007"}},
+	{"GPL-2.0-only", []string{"gnu general public license version 2 june 1991"}},
+	{"EPL-2.0", []string{"eclipse public license v 2 0"}},
+	{"Apache-2.0", []string{"apache license version 2 0"}},
+	{"BSL-1.0", []string{"boost software license version 1 0"}},
+	{"CC0-1.0", []string{"cc0 1 0 universal"}},
+	{"Unlicense", []string{"this is free and unencumbered software released into the public domain"}},
+	{"BSD-3-Clause", []string{
+		"redistribution and use in source and binary forms with or without modification are permitted",
+		"used to endorse or promote products derived from this software",
+	}},
+	{"BSD-2-Clause", []string{"redistribution and use in source and binary forms with or without modification are permitted"}},
+	{"MIT", []string{
+		"permission is hereby granted free of charge to any person obtaining a copy",
+		"the above copyright notice and this permission notice shall be included",
+	}},
+	{"ISC", []string{
+		"permission to use copy modify and or distribute this software for any purpose with or without fee is hereby granted",
+		"provided that the above copyright notice and this permission notice appear in all copies",
+	}},
+	{"0BSD", []string{"permission to use copy modify and or distribute this software for any purpose with or without fee is hereby granted"}},
+	{"Zlib", []string{
+		"in no event will the authors be held liable for any damages arising from the use of this software",
+		"altered source versions must be plainly marked as such",
+	}},
+}
+
+// spdxIdentifier matches an SPDX-License-Identifier line, with the rest of the line being the
+// license expression, like GPL-2.0-only OR MIT.
+var spdxIdentifier = regexp.MustCompile(`SPDX-License-Identifier:[ \t]*([^\r\n]*)`)
+
+// Identify returns the SPDX identifier of the license in a license text, or the expression of
+// an explicit SPDX-License-Identifier, which wins over the text.
+func Identify(text string) (string, bool) {
+	if m := spdxIdentifier.FindStringSubmatch(text); m != nil {
+		// the end of a comment, as in /* SPDX-License-Identifier: MIT */
+		expr := strings.TrimSpace(m[1])
+		for _, end := range []string{"*/", "-->"} {
+			expr = strings.TrimSpace(strings.TrimSuffix(expr, end))
+		}
+		if expr != "" {
+			return strings.Join(strings.Fields(expr), " "), true
+		}
+	}
+	norm := normalize(text)
+	for _, l := range licenses {
+		if containsAll(norm, l.phrases) {
+			return l.id, true
+		}
+	}
+	return "", false
+}
+
+// normalize lower-cases the text and turns every run of other characters than letters and
+// digits into a single space, so formatting, line breaks and punctuation don't matter.
+func normalize(text string) string {
+	var b strings.Builder
+	space := true
+	for _, r := range strings.ToLower(text) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			space = false
+		} else if !space {
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	return b.String()
+}
+
+func containsAll(text string, phrases []string) bool {
+	for _, p := range phrases {
+		if !strings.Contains(text, p) {
+			return false
+		}
+	}
+	return true
+}
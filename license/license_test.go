@@ -0,0 +1,196 @@
+package license
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const mit = `MIT License
+
+Copyright (c) 2023 Someone
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction.
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+`
+
+const bsd3 = `Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are met:
+
+* Neither the name of the copyright holder nor the names of its
+  contributors may be used to endorse or promote products derived from
+  this software without specific prior written permission.
+`
+
+const bsd2 = `Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are met:
+
+1. Redistributions of source code must retain the above copyright notice.
+`
+
+const apache = `
+                                 Apache License
+                           Version 2.0, January 2004
+                        http://www.apache.org/licenses/
+`
+
+func TestIdentify(t *testing.T) {
+	tests := []struct {
+		name, text, want string
+	}{
+		{"mit", mit, "MIT"},
+		{"bsd-3", bsd3, "BSD-3-Clause"},
+		{"bsd-3 contributors", "Redistribution and use in source and binary forms, with or without modification, are permitted. " +
+			"The names of its contributors may not be used to endorse or promote products derived from this software.", "BSD-3-Clause"},
+		{"bsd-2", bsd2, "BSD-2-Clause"},
+		{"apache", apache, "Apache-2.0"},
+		{"lgpl", "GNU LESSER GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007\n\nThis version of the GNU General Public License...", "LGPL-3.0-only"},
+		{"agpl", agpl, "AGPL-3.0-only"},
+		{"spdx header", "// SPDX-License-Identifier: MPL-2.0\n" + mit, "MPL-2.0"},
+		{"spdx expression", "/* SPDX-License-Identifier: GPL-2.0-only OR MIT */\n", "GPL-2.0-only OR MIT"},
+		{"spdx exception", "# SPDX-License-Identifier: Apache-2.0 WITH LLVM-exception\n" + apache, "Apache-2.0 WITH LLVM-exception"},
+		{"unknown", "All rights reserved.", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Identify(tt.text)
+			if got != tt.want || ok != (tt.want != "") {
+				t.Errorf("got %q, %v, want %q", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+// agpl is the start and section 13 of the AGPL, which sets it apart from the GPL, whose own
+// section 13 is about the AGPL.
+const agpl = `                    GNU AFFERO GENERAL PUBLIC LICENSE
+                       Version 3, 19 November 2007
+
+  13. Remote Network Interaction; Use with the GNU General Public License.
+
+  Notwithstanding any other provision of this License, if you modify the
+Program, your modified version must prominently offer all users
+interacting with it remotely through a computer network (if your version
+supports such interaction) an opportunity to receive the Corresponding
+Source of your version by providing access to the Corresponding Source
+from a network server at no charge, through some standard or customary
+means of facilitating copying of software.
+`
+
+// TestIdentifyTexts identifies the full license texts in testdata, named by SPDX identifier.
+func TestIdentifyTexts(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.txt"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no license texts: %v", err)
+	}
+	for _, name := range files {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := strings.TrimSuffix(filepath.Base(name), ".txt")
+		if got, _ := Identify(string(data)); got != want {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	fsys := fstest.MapFS{
+		"LICENSE-MIT":    {Data: []byte(mit)},
+		"LICENSE-APACHE": {Data: []byte(apache)},
+		"COPYING.txt":    {Data: []byte("All rights reserved.")},
+		"LICENSES/x.txt": {Data: []byte(bsd2)},
+		"licensed.go":    {Data: []byte("package licensed")},
+		"README.md":      {Data: []byte(bsd3)},
+	}
+	info, err := Detect(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Files) != 3 || info.Expression() != "Apache-2.0 AND MIT" {
+		t.Errorf("got %+v", info)
+	}
+	if info, _ := Detect(fstest.MapFS{"go.mod": {}}); info.Found() {
+		t.Errorf("found a license in %+v", info)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	p := &Policy{Allow: []string{"MIT", "Apache-2.0"}, Deny: []string{"AGPL-3.0", "GPL-3.0"}}
+	tests := []struct {
+		ids  []string
+		want Verdict
+	}{
+		{[]string{"MIT"}, Allowed},
+		{[]string{"AGPL-3.0"}, Denied},
+		// the licenses of several files all apply:
+		{[]string{"AGPL-3.0", "MIT"}, Denied},
+		{[]string{"ISC", "MIT"}, Unlisted},
+		{[]string{"Apache-2.0", "MIT"}, Allowed},
+		{[]string{"ISC"}, Unlisted},
+		// alternatives only come from an SPDX expression:
+		{[]string{"GPL-3.0 OR MIT"}, Allowed},
+		{[]string{"AGPL-3.0 OR ISC"}, Unlisted},
+		{[]string{"GPL-3.0 AND MIT"}, Denied},
+		{[]string{"(GPL-3.0 OR Apache-2.0) AND MIT"}, Allowed},
+		{[]string{"GPL-3.0 OR Apache-2.0 AND ISC"}, Unlisted},
+		{[]string{"Apache-2.0 WITH LLVM-exception"}, Allowed},
+		{[]string{"MIT OR"}, Unlisted},
+		{nil, Unknown},
+	}
+	for _, tt := range tests {
+		if got := p.Evaluate(Info{IDs: tt.ids}); got != tt.want {
+			t.Errorf("%v: got %s, want %s", tt.ids, got, tt.want)
+		}
+	}
+	if got := (Info{IDs: []string{"GPL-2.0-only OR MIT", "ISC"}}).Expression(); got != "(GPL-2.0-only OR MIT) AND ISC" {
+		t.Errorf("expression: %s", got)
+	}
+	if got := (&Policy{Deny: []string{"GPL-3.0"}}).Evaluate(Info{IDs: []string{"ISC"}}); got != Allowed {
+		t.Errorf("deny-only policy: got %s", got)
+	}
+	var none *Policy
+	if got := none.Evaluate(Info{IDs: []string{"GPL-3.0"}}); got != Allowed {
+		t.Errorf("nil policy: got %s", got)
+	}
+}
+
+// TestEvaluateDeprecatedIDs applies policies listing the deprecated and the current identifier
+// of the GPL to a LICENSE text and to an SPDX-License-Identifier header.
+func TestEvaluateDeprecatedIDs(t *testing.T) {
+	gpl, err := os.ReadFile(filepath.Join("testdata", "GPL-3.0-only.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources := map[string]string{
+		"text":   string(gpl),
+		"header": "// SPDX-License-Identifier: GPL-3.0-only\n",
+		"old":    "// SPDX-License-Identifier: GPL-3.0\n",
+	}
+	for _, deny := range []string{"GPL-3.0", "GPL-3.0-only"} {
+		p := &Policy{Deny: []string{deny}}
+		for name, src := range sources {
+			info, err := Detect(fstest.MapFS{"LICENSE": {Data: []byte(src)}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.Evaluate(info); got != Denied {
+				t.Errorf("deny %s, %s %v: got %s", deny, name, info.IDs, got)
+			}
+		}
+	}
+	later := &Policy{Deny: []string{"GPL-3.0+"}}
+	if got := later.Evaluate(Info{IDs: []string{"GPL-3.0-or-later"}}); got != Denied {
+		t.Errorf("deny GPL-3.0+, GPL-3.0-or-later: got %s", got)
+	}
+	if got := later.Evaluate(Info{IDs: []string{"GPL-3.0-only"}}); got != Allowed {
+		t.Errorf("deny GPL-3.0+, GPL-3.0-only: got %s", got)
+	}
+}
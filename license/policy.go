@@ -0,0 +1,198 @@
+package license
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Verdict is what a policy says about the license of a module.
+type Verdict int
+
+const (
+	Allowed  Verdict = iota + 1
+	Denied           // a license the module must be used under is on the deny list
+	Unlisted         // the policy has an allow list, and a license the module must be used under isn't on it
+	Unknown          // no license was recognized
+)
+
+//go:generate go run golang.org/x/tools/cmd/stringer@latest -type=Verdict
+
+// Policy lists the licenses allowed and denied, by SPDX identifier. With an empty allow list,
+// every license that isn't denied is allowed. Deprecated identifiers like GPL-3.0 are taken as
+// their current form, GPL-3.0-only.
+type Policy struct {
+	Allow []string `json:"allow"`
+	Deny  []string `json:"deny"`
+}
+
+// LoadPolicy reads a policy from a JSON file, like {"allow": ["MIT"], "deny": ["AGPL-3.0"]}.
+func LoadPolicy(name string) (*Policy, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("os.ReadFile: %w", err)
+	}
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	for _, id := range p.Allow {
+		if slices.Contains(p.Deny, id) {
+			return nil, fmt.Errorf("%s: %s is both allowed and denied", name, id)
+		}
+	}
+	return &p, nil
+}
+
+// Evaluate applies the policy to the licenses of a module. The licenses of its files all apply,
+// so the module is denied if any of them is. Only an OR in an SPDX expression makes licenses
+// alternatives, where the module is allowed if one of them is. A nil policy allows every
+// license.
+func (p *Policy) Evaluate(i Info) Verdict {
+	if len(i.IDs) == 0 {
+		return Unknown
+	}
+	if p == nil {
+		return Allowed
+	}
+	verdict := Allowed
+	for _, id := range i.IDs {
+		verdict = worst(verdict, p.evaluate(id))
+	}
+	return verdict
+}
+
+// evaluate applies the policy to an SPDX license expression. An expression that doesn't parse is
+// taken as a single license.
+func (p *Policy) evaluate(expr string) Verdict {
+	e := &expression{tokens: tokenize(expr)}
+	v, ok := e.or(p)
+	if !ok || e.pos != len(e.tokens) {
+		return p.license(expr)
+	}
+	return v
+}
+
+// license applies the policy to a single license, like MIT or Apache-2.0 WITH LLVM-exception,
+// which is looked up with and then without its exception.
+func (p *Policy) license(id string) Verdict {
+	ids := []string{id}
+	if base, _, ok := strings.Cut(id, " WITH "); ok {
+		ids = append(ids, base)
+	}
+	for _, id := range ids {
+		same := func(listed string) bool {
+			return canonical(listed) == canonical(id)
+		}
+		switch {
+		case slices.ContainsFunc(p.Deny, same):
+			return Denied
+		case slices.ContainsFunc(p.Allow, same):
+			return Allowed
+		}
+	}
+	if len(p.Allow) == 0 {
+		return Allowed
+	}
+	return Unlisted
+}
+
+// deprecatedID matches the deprecated SPDX identifiers of the GNU licenses, like GPL-3.0 and
+// GPL-3.0+, which are GPL-3.0-only and GPL-3.0-or-later today.
+var deprecatedID = regexp.MustCompile(`^((?:A|L)?GPL|GFDL)-(\d\.\d)(\+?)$`)
+
+// canonical returns the current SPDX identifier of a license, so a policy listing GPL-3.0 also
+// matches GPL-3.0-only, and the other way around.
+func canonical(id string) string {
+	m := deprecatedID.FindStringSubmatch(id)
+	if m == nil {
+		return id
+	}
+	if m[3] == "+" {
+		return m[1] + "-" + m[2] + "-or-later"
+	}
+	return m[1] + "-" + m[2] + "-only"
+}
+
+// severity orders the verdicts from allowed to denied.
+var severity = map[Verdict]int{Allowed: 0, Unlisted: 1, Denied: 2}
+
+func worst(a, b Verdict) Verdict {
+	if severity[b] > severity[a] {
+		return b
+	}
+	return a
+}
+
+func best(a, b Verdict) Verdict {
+	if severity[b] < severity[a] {
+		return b
+	}
+	return a
+}
+
+// expression parses an SPDX license expression while evaluating it. AND binds tighter than
+// OR, WITH tighter than both.
+type expression struct {
+	tokens []string
+	pos    int
+}
+
+func tokenize(expr string) []string {
+	expr = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expr)
+	return strings.Fields(expr)
+}
+
+func (e *expression) peek() string {
+	if e.pos < len(e.tokens) {
+		return e.tokens[e.pos]
+	}
+	return ""
+}
+
+func (e *expression) or(p *Policy) (Verdict, bool) {
+	v, ok := e.and(p)
+	for ok && strings.EqualFold(e.peek(), "OR") {
+		e.pos++
+		var w Verdict
+		w, ok = e.and(p)
+		v = best(v, w)
+	}
+	return v, ok
+}
+
+func (e *expression) and(p *Policy) (Verdict, bool) {
+	v, ok := e.term(p)
+	for ok && strings.EqualFold(e.peek(), "AND") {
+		e.pos++
+		var w Verdict
+		w, ok = e.term(p)
+		v = worst(v, w)
+	}
+	return v, ok
+}
+
+func (e *expression) term(p *Policy) (Verdict, bool) {
+	switch tok := e.peek(); {
+	case tok == "(":
+		e.pos++
+		v, ok := e.or(p)
+		if !ok || e.peek() != ")" {
+			return 0, false
+		}
+		e.pos++
+		return v, true
+	case tok == "", tok == ")", strings.EqualFold(tok, "AND"), strings.EqualFold(tok, "OR"), strings.EqualFold(tok, "WITH"):
+		return 0, false
+	default:
+		e.pos++
+		if strings.EqualFold(e.peek(), "WITH") && e.pos+1 < len(e.tokens) {
+			tok += " WITH " + e.tokens[e.pos+1]
+			e.pos += 2
+		}
+		return p.license(tok), true
+	}
+}
@@ -2,14 +2,26 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"github.com/perbu/gogrok/analytics"
+	"github.com/perbu/gogrok/discovery"
+	"github.com/perbu/gogrok/export"
+	"github.com/perbu/gogrok/license"
 	"github.com/perbu/gogrok/render"
+	"github.com/perbu/gogrok/reposync"
+	"github.com/perbu/gogrok/sbom"
+	"github.com/perbu/gogrok/watch"
 	"io"
 	"log/slog"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/dustin/go-humanize"
 )
@@ -24,11 +36,56 @@ func main() {
 	}
 }
 
+// defaultManifest lists the repositories to check out into code/, see reposync.LoadManifest.
+const defaultManifest = "repos.json"
+
 func run(ctx context.Context, output io.Writer, env, args []string) error {
-	r, err := analytics.New("code")
+	if len(args) > 1 {
+		switch args[1] {
+		case "sync":
+			return runSync(ctx, output, args[1:])
+		case "discover":
+			return runDiscover(ctx, output, env, args[1:])
+		case "export":
+			return runExport(output, env, args[1:])
+		case "sbom":
+			return runSBOM(output, env, args[1:])
+		}
+	}
+	var config analytics.Config
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.BoolVar(&config.IncludeTests, "include-tests", false, "count test files in the LoC, file and complexity metrics")
+	flags.StringVar(&config.CoverageDir, "coverage", "", "directory with coverage profiles (go test -coverprofile) to import")
+	flags.DurationVar(&config.HistoryWindow, "history-window", 0, "how far back to walk the git history, like 8760h for a year, 0 to skip (slow)")
+	flags.BoolVar(&config.Ownership, "ownership", false, "run git blame to compute code ownership and bus factors (slow)")
+	manifest := flags.String("manifest", defaultManifest, "manifest of the repositories the Sync page clones and updates")
+	watchCode := flags.Bool("watch", true, "watch code/ and re-analyze checkouts when they change")
+	flags.StringVar(&config.CacheFile, "cache", ".analysis.bolt.db", "file caching the analysis of unchanged source files between runs, empty to disable")
+	flags.StringVar(&config.ModCache, "modcache", defaultModCache(env), "module cache to read the licenses and source of external modules from, empty to skip")
+	flags.BoolVar(&config.LoadExternal, "load-external", false, "load the source of external modules from the module cache, to browse them and resolve imports into their packages")
+	flagStdlib := flags.String("flag-stdlib", "", "comma separated standard library packages to flag, on top of the deprecated ones")
+	policy := flags.String("license-policy", "", `JSON file with the licenses to allow and deny, like {"allow": ["MIT"], "deny": ["AGPL-3.0"]}`)
+	err := flags.Parse(args[1:])
+	if err != nil {
+		return fmt.Errorf("flags.Parse: %w", err)
+	}
+	config.FlagStdlib = splitList(*flagStdlib)
+	if *policy != "" {
+		config.LicensePolicy, err = license.LoadPolicy(*policy)
+		if err != nil {
+			return fmt.Errorf("license.LoadPolicy: %w", err)
+		}
+	}
+	r, err := analytics.New("code", config)
 	if err != nil {
 		return fmt.Errorf("analytics.New: %w", err)
 	}
+	defer func() {
+		if err := r.Close(); err != nil {
+			slog.Error("r.Close", "error", err)
+		}
+	}()
 	err = r.Parse()
 	if err != nil {
 		return fmt.Errorf("r.Parse: %w", err)
@@ -41,6 +98,26 @@ func run(ctx context.Context, output io.Writer, env, args []string) error {
 	if err != nil {
 		return fmt.Errorf("render.New: %w", err)
 	}
+	s.SetSyncer(reposync.NewSyncer(*manifest, "code", 4))
+	if *watchCode {
+		w, err := watch.New("code", 2*time.Second)
+		if err != nil {
+			return fmt.Errorf("watch.New: %w", err)
+		}
+		go func() {
+			err := w.Run(ctx, func(changed []string) {
+				next, err := s.Repo().Rebuild(changed)
+				if err != nil {
+					logger.Error("re-analysis failed, still serving the previous analysis", "error", err)
+					return
+				}
+				s.SetRepo(next)
+			})
+			if err != nil {
+				logger.Error("w.Run", "error", err)
+			}
+		}()
+	}
 	err = s.Start(ctx)
 	if err != nil {
 		return fmt.Errorf("s.Start: %w", err)
@@ -49,6 +126,263 @@ func run(ctx context.Context, output io.Writer, env, args []string) error {
 	return nil
 }
 
+// runSync implements "gogrok sync": clone and update the repositories of the manifest, then
+// print the status of each. It fails if any of them isn't in sync.
+func runSync(ctx context.Context, output io.Writer, args []string) error {
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(output)
+	manifest := flags.String("manifest", defaultManifest, "manifest of the repositories to clone and update")
+	parallel := flags.Int("parallel", 4, "number of repositories synced at a time")
+	err := flags.Parse(args[1:])
+	if err != nil {
+		return fmt.Errorf("flags.Parse: %w", err)
+	}
+	m, err := reposync.LoadManifest(*manifest)
+	if err != nil {
+		return fmt.Errorf("reposync.LoadManifest: %w", err)
+	}
+	results := reposync.Sync(ctx, "code", m, *parallel)
+	tw := tabwriter.NewWriter(output, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "DIR\tSTATUS\tCOMMIT\tDETAILS")
+	failed := 0
+	for _, r := range results {
+		if !r.OK() {
+			failed++
+		}
+		commit := r.After
+		if len(commit) > 7 {
+			commit = commit[:7]
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Repo.Dir, r.Status, commit, r.Note)
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("tw.Flush: %w", err)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d repositories not in sync", failed, len(results))
+	}
+	return nil
+}
+
+// runDiscover implements "gogrok discover": list the repositories of an organisation on GitHub
+// or Gitea and write the ones passing the filters to the manifest "gogrok sync" reads.
+func runDiscover(ctx context.Context, output io.Writer, env, args []string) error {
+	var filter discovery.Filter
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(output)
+	provider := flags.String("provider", "github", "hosting service API: github or gitea")
+	baseURL := flags.String("base-url", "", "API base URL, for GitHub Enterprise or the Gitea instance")
+	org := flags.String("org", "", "organisation (or user) whose repositories to list")
+	topics := flags.String("topic", "", "comma separated topics, keep repositories with any of them")
+	languages := flags.String("language", "", "comma separated languages, keep repositories in any of them")
+	flags.BoolVar(&filter.Archived, "archived", false, "include archived repositories")
+	flags.BoolVar(&filter.Forks, "forks", false, "include forks")
+	ssh := flags.Bool("ssh", false, "clone over SSH instead of HTTPS")
+	out := flags.String("o", defaultManifest, "manifest to write, - for stdout")
+	err := flags.Parse(args[1:])
+	if err != nil {
+		return fmt.Errorf("flags.Parse: %w", err)
+	}
+	if *org == "" {
+		return fmt.Errorf("-org is required")
+	}
+	filter.Topics = splitList(*topics)
+	filter.Languages = splitList(*languages)
+	// GITHUB_TOKEN or GITEA_TOKEN:
+	token := lookupEnv(env, strings.ToUpper(*provider)+"_TOKEN")
+	p, err := discovery.New(*provider, *baseURL, token)
+	if err != nil {
+		return fmt.Errorf("discovery.New: %w", err)
+	}
+	repos, err := p.Repositories(ctx, *org)
+	if err != nil {
+		return fmt.Errorf("p.Repositories: %w", err)
+	}
+	m := discovery.Manifest(repos, filter, *ssh)
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("json.MarshalIndent: %w", err)
+	}
+	data = append(data, '\n')
+	if *out == "-" {
+		_, err = output.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		return fmt.Errorf("os.WriteFile: %w", err)
+	}
+	_, _ = fmt.Fprintf(output, "wrote %d of %d repositories to %s\n", len(m.Repos), len(repos), *out)
+	return nil
+}
+
+// runExport implements "gogrok export": analyze code/ and write the module or package graph
+// in a format for Graphviz, Mermaid or other tools.
+func runExport(output io.Writer, env, args []string) error {
+	var o export.Options
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(output)
+	graph := flags.String("graph", "modules", "graph to export: modules or packages")
+	format := flags.String("format", "dot", "output format: dot, mermaid, graphml or json")
+	flags.StringVar(&o.Prefix, "prefix", "", "only start from local modules whose path starts with this")
+	flags.BoolVar(&o.LocalOnly, "local", false, "leave out external modules and packages")
+	flags.IntVar(&o.Depth, "depth", 0, "how many edges away from the local modules to go, 0 for no limit")
+	cache := flags.String("cache", ".analysis.bolt.db", "file caching the analysis of unchanged source files between runs, empty to disable")
+	modCache := flags.String("modcache", defaultModCache(env), "module cache to read the licenses of external modules from, empty to skip")
+	out := flags.String("o", "-", "file to write, - for stdout")
+	err := flags.Parse(args[1:])
+	if err != nil {
+		return fmt.Errorf("flags.Parse: %w", err)
+	}
+	f, err := export.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+	switch *graph {
+	case "modules":
+	case "packages":
+		o.Packages = true
+	default:
+		return fmt.Errorf("-graph must be modules or packages")
+	}
+	// the graphs don't need the git history:
+	r, err := analytics.New("code", analytics.Config{CacheFile: *cache, ModCache: *modCache})
+	if err != nil {
+		return fmt.Errorf("analytics.New: %w", err)
+	}
+	defer func() {
+		if err := r.Close(); err != nil {
+			slog.Error("r.Close", "error", err)
+		}
+	}()
+	if err := r.Parse(); err != nil {
+		return fmt.Errorf("r.Parse: %w", err)
+	}
+	g := export.Graph(r, o)
+	if *out == "-" {
+		return export.Write(output, g, f)
+	}
+	file, err := os.Create(*out)
+	if err != nil {
+		return fmt.Errorf("os.Create: %w", err)
+	}
+	if err := export.Write(file, g, f); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("export.Write: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("file.Close: %w", err)
+	}
+	_, _ = fmt.Fprintf(output, "wrote %d nodes and %d edges to %s\n", len(g.Nodes), len(g.Edges), *out)
+	return nil
+}
+
+// runSBOM implements "gogrok sbom": write a software bill of materials for every local module,
+// or a single one, into a directory.
+func runSBOM(output io.Writer, env, args []string) error {
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(output)
+	format := flags.String("format", "cyclonedx", "output format: cyclonedx or spdx")
+	module := flags.String("module", "", "only this module, all local modules if empty")
+	cache := flags.String("cache", ".analysis.bolt.db", "file caching the analysis of unchanged source files between runs, empty to disable")
+	modCache := flags.String("modcache", defaultModCache(env), "module cache to read the licenses of external modules from, empty to skip")
+	out := flags.String("o", "sbom", "directory to write to, - for stdout with -module")
+	err := flags.Parse(args[1:])
+	if err != nil {
+		return fmt.Errorf("flags.Parse: %w", err)
+	}
+	f, err := sbom.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+	if *out == "-" && *module == "" {
+		return fmt.Errorf("-o - needs -module")
+	}
+	r, err := analytics.New("code", analytics.Config{CacheFile: *cache, ModCache: *modCache})
+	if err != nil {
+		return fmt.Errorf("analytics.New: %w", err)
+	}
+	defer func() {
+		if err := r.Close(); err != nil {
+			slog.Error("r.Close", "error", err)
+		}
+	}()
+	if err := r.Parse(); err != nil {
+		return fmt.Errorf("r.Parse: %w", err)
+	}
+	mods := r.ModuleFilter(analytics.DepTypeLocal, "")
+	if *module != "" {
+		m, ok := r.GetModule(*module)
+		if !ok || m.Type != analytics.DepTypeLocal {
+			return fmt.Errorf("no local module %s", *module)
+		}
+		mods = []analytics.Module{*m}
+	}
+	if *out != "-" {
+		if err := os.MkdirAll(*out, 0o755); err != nil {
+			return fmt.Errorf("os.MkdirAll: %w", err)
+		}
+	}
+	for _, m := range mods {
+		doc, err := sbom.New(&m)
+		if err != nil {
+			return err
+		}
+		if *out == "-" {
+			return doc.Write(output, f)
+		}
+		name := filepath.Join(*out, sbom.Filename(m.Path, f))
+		file, err := os.Create(name)
+		if err != nil {
+			return fmt.Errorf("os.Create: %w", err)
+		}
+		if err := doc.Write(file, f); err != nil {
+			_ = file.Close()
+			return fmt.Errorf("doc.Write: %w", err)
+		}
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("file.Close: %w", err)
+		}
+		_, _ = fmt.Fprintf(output, "wrote %s, %d required modules\n", name, len(doc.Requires))
+	}
+	return nil
+}
+
+// splitList splits a comma separated flag value, leaving out empty elements.
+func splitList(s string) []string {
+	var list []string
+	for _, e := range strings.Split(s, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			list = append(list, e)
+		}
+	}
+	return list
+}
+
+// defaultModCache returns where the go command extracts modules: GOMODCACHE, or pkg/mod in the
+// first GOPATH entry, or in ~/go.
+func defaultModCache(env []string) string {
+	if dir := lookupEnv(env, "GOMODCACHE"); dir != "" {
+		return dir
+	}
+	if gopath := filepath.SplitList(lookupEnv(env, "GOPATH")); len(gopath) > 0 && gopath[0] != "" {
+		return filepath.Join(gopath[0], "pkg", "mod")
+	}
+	if home := lookupEnv(env, "HOME"); home != "" {
+		return filepath.Join(home, "go", "pkg", "mod")
+	}
+	return ""
+}
+
+// lookupEnv finds a variable in an environment given as key=value pairs.
+func lookupEnv(env []string, key string) string {
+	for _, kv := range env {
+		if k, v, ok := strings.Cut(kv, "="); ok && k == key {
+			return v
+		}
+	}
+	return ""
+}
+
 func logMemoryUsage(logger *slog.Logger) {
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
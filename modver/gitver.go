@@ -37,3 +37,8 @@ func fetchLocalTags(repoPath string) ([]string, error) {
 	semver.Sort(tags)
 	return tags, nil
 }
+
+// LocalTags returns the tags of the git repository at repoPath, in semver order.
+func LocalTags(repoPath string) ([]string, error) {
+	return fetchLocalTags(repoPath)
+}
@@ -69,6 +69,11 @@ func (m *ModTracker) GetTags(ctx context.Context, module string) ([]string, erro
 	// 2. Lowercase the module path
 	module = strings.ToLower(module)
 
+	// Local checkouts are cheap to read and change with every pull or new tag, don't cache them.
+	if strings.HasPrefix(module, "code/") {
+		return fetchLocalTags(module)
+	}
+
 	// 3. Attempt to fetch from cache
 	var tags []string
 	err := m.cache.View(func(tx *bolt.Tx) error {
@@ -0,0 +1,361 @@
+package apiv1
+
+import (
+	_ "embed"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/perbu/gogrok/analytics"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+//go:embed openapi.json
+var openapi []byte
+
+const (
+	defaultPerPage = 50
+	maxPerPage     = 500
+	defaultDepth   = 2
+	maxDepth       = 10
+)
+
+// API serves the analysis as JSON. Modules, packages and files are identified by query
+// parameters (module, package, file), and lists are paginated with page and per_page.
+type API struct {
+	repo func() *analytics.Repo // the analysis currently served
+}
+
+// Register adds the v1 routes to router, which is expected to be mounted at /api/v1.
+func Register(router *mux.Router, repo func() *analytics.Repo) {
+	a := &API{repo: repo}
+	router.HandleFunc("/openapi.json", handleOpenAPI).Methods(http.MethodGet)
+	router.HandleFunc("/overview", a.handleOverview).Methods(http.MethodGet)
+	router.HandleFunc("/modules", a.handleModules).Methods(http.MethodGet)
+	router.HandleFunc("/module", a.handleModule).Methods(http.MethodGet)
+	router.HandleFunc("/dependencies", a.handleDependencies).Methods(http.MethodGet)
+	router.HandleFunc("/dependents", a.handleDependents).Methods(http.MethodGet)
+	router.HandleFunc("/versions", a.handleVersions).Methods(http.MethodGet)
+	router.HandleFunc("/packages", a.handlePackages).Methods(http.MethodGet)
+	router.HandleFunc("/package", a.handlePackage).Methods(http.MethodGet)
+	router.HandleFunc("/files", a.handleFiles).Methods(http.MethodGet)
+	router.HandleFunc("/file", a.handleFile).Methods(http.MethodGet)
+	router.HandleFunc("/graph/modules", a.handleModuleGraph).Methods(http.MethodGet)
+	router.HandleFunc("/graph/packages", a.handlePackageGraph).Methods(http.MethodGet)
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "no such endpoint")
+	})
+}
+
+func handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, err := w.Write(openapi)
+	if err != nil {
+		slog.Error("write", "endpoint", "openapi.json", "error", err)
+	}
+}
+
+func (a *API) handleOverview(w http.ResponseWriter, r *http.Request) {
+	repo := a.repo()
+	var o Overview
+	complexity := float64(0)
+	for _, mod := range repo.ModuleFilter(analytics.DepTypeLocal, "") {
+		o.LocalModules++
+		o.Packages += len(mod.Packages)
+		o.Files += mod.Files()
+		o.LoC += mod.Lines()
+		o.TestLoC += mod.TestLines()
+		o.OutdatedDependencies += len(mod.OutdatedDependencies())
+		complexity += number(mod.CalculateComplexity())
+	}
+	if o.LocalModules > 0 {
+		o.Complexity = complexity / float64(o.LocalModules)
+	}
+	o.ExternalModules = len(repo.ModuleFilter(analytics.DepTypeExternal, ""))
+	writeJSON(w, o)
+}
+
+// handleModules lists the modules, optionally only those of a type (local or external) or
+// with a substring in their path (q).
+func (a *API) handleModules(w http.ResponseWriter, r *http.Request) {
+	repo := a.repo()
+	q := r.URL.Query().Get("q")
+	var mods []analytics.Module
+	switch t := r.URL.Query().Get("type"); t {
+	case "":
+		mods = append(repo.ModuleFilter(analytics.DepTypeLocal, q), repo.ModuleFilter(analytics.DepTypeExternal, q)...)
+		sort.Slice(mods, func(i, j int) bool {
+			return mods[i].Path < mods[j].Path
+		})
+	case "local":
+		mods = repo.ModuleFilter(analytics.DepTypeLocal, q)
+	case "external":
+		mods = repo.ModuleFilter(analytics.DepTypeExternal, q)
+	default:
+		writeError(w, http.StatusBadRequest, "type must be local or external")
+		return
+	}
+	page, ok := paginate(w, r, mods)
+	if !ok {
+		return
+	}
+	writeJSON(w, convert(page, func(m analytics.Module) ModuleSummary {
+		return newModuleSummary(&m)
+	}))
+}
+
+func (a *API) handleModule(w http.ResponseWriter, r *http.Request) {
+	mod, ok := a.lookupModule(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, newModule(mod))
+}
+
+func (a *API) handleDependencies(w http.ResponseWriter, r *http.Request) {
+	mod, ok := a.lookupModule(w, r)
+	if !ok {
+		return
+	}
+	page, ok := paginate(w, r, newDependencies(mod))
+	if !ok {
+		return
+	}
+	writeJSON(w, page)
+}
+
+// handleDependents lists the local modules requiring the module. Snapshots have no dependents.
+func (a *API) handleDependents(w http.ResponseWriter, r *http.Request) {
+	mod, ok := a.lookupModule(w, r)
+	if !ok {
+		return
+	}
+	page, ok := paginate(w, r, newDependents(mod))
+	if !ok {
+		return
+	}
+	writeJSON(w, page)
+}
+
+func (a *API) handleVersions(w http.ResponseWriter, r *http.Request) {
+	mod, ok := a.lookupModule(w, r)
+	if !ok {
+		return
+	}
+	versions := mod.GetVersions()
+	if versions == nil {
+		versions = []string{}
+	}
+	writeJSON(w, Versions{Module: mod.Path, Latest: mod.Latest(), Versions: versions})
+}
+
+func (a *API) handlePackages(w http.ResponseWriter, r *http.Request) {
+	mod, ok := a.lookupModule(w, r)
+	if !ok {
+		return
+	}
+	pkgs := make([]*analytics.Package, len(mod.Packages))
+	copy(pkgs, mod.Packages)
+	sort.Slice(pkgs, func(i, j int) bool {
+		return pkgs[i].Name < pkgs[j].Name
+	})
+	page, ok := paginate(w, r, pkgs)
+	if !ok {
+		return
+	}
+	writeJSON(w, convert(page, newPackageSummary))
+}
+
+func (a *API) handlePackage(w http.ResponseWriter, r *http.Request) {
+	pkg, ok := a.lookupPackage(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, newPackage(pkg))
+}
+
+func (a *API) handleFiles(w http.ResponseWriter, r *http.Request) {
+	pkg, ok := a.lookupPackage(w, r)
+	if !ok {
+		return
+	}
+	files := make([]*analytics.File, len(pkg.GetFiles()))
+	copy(files, pkg.GetFiles())
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	page, ok := paginate(w, r, files)
+	if !ok {
+		return
+	}
+	writeJSON(w, convert(page, newFileSummary))
+}
+
+// handleFile returns a file, with its source if the source parameter is true.
+func (a *API) handleFile(w http.ResponseWriter, r *http.Request) {
+	pkg, ok := a.lookupPackage(w, r)
+	if !ok {
+		return
+	}
+	file, ok := pkg.GetFile(r.URL.Query().Get("file"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "file not found")
+		return
+	}
+	source, _ := strconv.ParseBool(r.URL.Query().Get("source"))
+	writeJSON(w, newFile(file, source))
+}
+
+// handleModuleGraph returns the neighbourhood of a module in the module dependency graph.
+func (a *API) handleModuleGraph(w http.ResponseWriter, r *http.Request) {
+	opts, ok := graphOptions(w, r)
+	if !ok {
+		return
+	}
+	name := r.URL.Query().Get("module")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "module parameter missing")
+		return
+	}
+	g, err := a.repo().ModuleGraph(name, opts)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "module not found")
+		return
+	}
+	writeJSON(w, newGraph(g))
+}
+
+// handlePackageGraph returns the neighbourhood of a package in the package import graph.
+func (a *API) handlePackageGraph(w http.ResponseWriter, r *http.Request) {
+	opts, ok := graphOptions(w, r)
+	if !ok {
+		return
+	}
+	name, pkg := r.URL.Query().Get("module"), r.URL.Query().Get("package")
+	if name == "" || pkg == "" {
+		writeError(w, http.StatusBadRequest, "module or package parameter missing")
+		return
+	}
+	g, err := a.repo().PackageGraph(name, pkg, opts)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "module or package not found")
+		return
+	}
+	writeJSON(w, newGraph(g))
+}
+
+// graphOptions reads the depth, direction and local parameters. It writes an error response
+// if they aren't valid.
+func graphOptions(w http.ResponseWriter, r *http.Request) (analytics.GraphOptions, bool) {
+	depth, err := intParam(r, "depth", defaultDepth)
+	if err != nil || depth < 1 || depth > maxDepth {
+		writeError(w, http.StatusBadRequest, "depth must be between 1 and "+strconv.Itoa(maxDepth))
+		return analytics.GraphOptions{}, false
+	}
+	dir, err := analytics.ParseDirection(r.URL.Query().Get("direction"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "direction must be deps, reverse or both")
+		return analytics.GraphOptions{}, false
+	}
+	local, _ := strconv.ParseBool(r.URL.Query().Get("local"))
+	return analytics.GraphOptions{Depth: depth, Direction: dir, LocalOnly: local}, true
+}
+
+// lookupModule finds the module given by the module parameter, at the git ref given by the
+// ref parameter if there is one. It writes an error response if there is no such module.
+func (a *API) lookupModule(w http.ResponseWriter, r *http.Request) (*analytics.Module, bool) {
+	name, ref := r.URL.Query().Get("module"), r.URL.Query().Get("ref")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "module parameter missing")
+		return nil, false
+	}
+	repo := a.repo()
+	if ref == "" {
+		mod, ok := repo.GetModule(name)
+		if !ok {
+			writeError(w, http.StatusNotFound, "module not found")
+		}
+		return mod, ok
+	}
+	mod, err := repo.ModuleAt(name, ref)
+	if err != nil {
+		slog.Warn("module snapshot", "module", name, "ref", ref, "error", err)
+		writeError(w, http.StatusNotFound, "module or ref not found")
+		return nil, false
+	}
+	return mod, true
+}
+
+// lookupPackage finds the package given by the module and package parameters.
+func (a *API) lookupPackage(w http.ResponseWriter, r *http.Request) (*analytics.Package, bool) {
+	mod, ok := a.lookupModule(w, r)
+	if !ok {
+		return nil, false
+	}
+	pkg, ok := mod.GetPackage(r.URL.Query().Get("package"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "package not found")
+	}
+	return pkg, ok
+}
+
+// paginate cuts out the page given by the page and per_page parameters. It writes an error
+// response if they aren't valid.
+func paginate[T any](w http.ResponseWriter, r *http.Request, items []T) (Page[T], bool) {
+	page, err := intParam(r, "page", 1)
+	if err != nil || page < 1 {
+		writeError(w, http.StatusBadRequest, "page must be a positive number")
+		return Page[T]{}, false
+	}
+	perPage, err := intParam(r, "per_page", defaultPerPage)
+	if err != nil || perPage < 1 || perPage > maxPerPage {
+		writeError(w, http.StatusBadRequest, "per_page must be between 1 and "+strconv.Itoa(maxPerPage))
+		return Page[T]{}, false
+	}
+	// pages past the end are empty, checked before multiplying so huge pages don't overflow
+	start := len(items)
+	if page-1 <= len(items)/perPage {
+		start = min((page-1)*perPage, len(items))
+	}
+	end := min(start+perPage, len(items))
+	p := Page[T]{Items: items[start:end], Page: page, PerPage: perPage, Total: len(items)}
+	if p.Items == nil {
+		p.Items = []T{}
+	}
+	return p, true
+}
+
+func intParam(r *http.Request, name string, def int) (int, error) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return def, nil
+	}
+	return strconv.Atoi(s)
+}
+
+// convert turns a page of analytics structs into a page of DTOs.
+func convert[T, D any](p Page[T], fn func(T) D) Page[D] {
+	items := make([]D, 0, len(p.Items))
+	for _, item := range p.Items {
+		items = append(items, fn(item))
+	}
+	return Page[D]{Items: items, Page: p.Page, PerPage: p.PerPage, Total: p.Total}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		slog.Error("json Encode", "endpoint", "v1", "error", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(Error{Error: msg})
+	if err != nil {
+		slog.Error("json Encode", "endpoint", "v1", "error", err)
+	}
+}
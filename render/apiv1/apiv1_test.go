@@ -0,0 +1,241 @@
+package apiv1
+
+import (
+	"encoding/json"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/gorilla/mux"
+	"github.com/perbu/gogrok/analytics"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeCheckout writes a tagged git checkout into code/.
+func writeCheckout(t *testing.T, name string, files map[string]string) {
+	t.Helper()
+	dir := filepath.Join("code", name)
+	for file, content := range files {
+		p := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.AddGlob("."); err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "dev", Email: "dev@example.com", When: time.Now()}
+	hash, err := wt.Commit("initial", &git.CommitOptions{Author: sig})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CreateTag("v1.0.0", hash, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testRouter analyzes a library and a service using it, and serves the API.
+func testRouter(t *testing.T) *mux.Router {
+	t.Helper()
+	// the analysis reads code/ and keeps its version cache in the working directory:
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	writeCheckout(t, "liba", map[string]string{
+		"go.mod":            "module example.com/liba\n\ngo 1.22\n",
+		"util/util.go":      "package util\n\n// Upper upper-cases.\nfunc Upper(s string) string {\n\tif s == \"\" {\n\t\treturn s\n\t}\n\treturn s\n}\n",
+		"util/util_test.go": "package util\n\nimport \"testing\"\n\nfunc TestUpper(t *testing.T) {}\n",
+	})
+	writeCheckout(t, "svc", map[string]string{
+		"go.mod":  "module example.com/svc\n\ngo 1.22\n\nrequire example.com/liba v1.0.0\n",
+		"main.go": "package main\n\nimport \"example.com/liba/util\"\n\nfunc main() {\n\tprintln(util.Upper(\"x\"))\n}\n",
+	})
+	repo, err := analytics.New("code", analytics.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Parse(); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	router := mux.NewRouter()
+	Register(router.PathPrefix("/api/v1").Subrouter(), func() *analytics.Repo { return repo })
+	return router
+}
+
+func get(t *testing.T, router *mux.Router, url string, wantStatus int, v any) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+	if rec.Code != wantStatus {
+		t.Fatalf("GET %s: status %d, want %d: %s", url, rec.Code, wantStatus, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("GET %s: content type %q", url, ct)
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("GET %s: %v: %s", url, err, rec.Body)
+	}
+}
+
+func TestAPI(t *testing.T) {
+	router := testRouter(t)
+
+	var modules Page[ModuleSummary]
+	get(t, router, "/api/v1/modules", http.StatusOK, &modules)
+	if modules.Total != 2 || modules.Items[0].Path != "example.com/liba" || modules.Items[0].Metrics == nil {
+		t.Errorf("modules: %+v", modules)
+	}
+	get(t, router, "/api/v1/modules?type=local&q=svc", http.StatusOK, &modules)
+	if modules.Total != 1 || modules.Items[0].Path != "example.com/svc" {
+		t.Errorf("modules filtered by q: %+v", modules)
+	}
+	get(t, router, "/api/v1/modules?per_page=1&page=2", http.StatusOK, &modules)
+	if modules.Total != 2 || len(modules.Items) != 1 || modules.Items[0].Path != "example.com/svc" {
+		t.Errorf("second page: %+v", modules)
+	}
+	get(t, router, "/api/v1/modules?per_page=2&page=4611686018427387905", http.StatusOK, &modules)
+	if modules.Total != 2 || len(modules.Items) != 0 {
+		t.Errorf("page past the end: %+v", modules)
+	}
+
+	var mod Module
+	get(t, router, "/api/v1/module?module=example.com/liba", http.StatusOK, &mod)
+	if mod.Type != "local" || mod.Packages != 1 || mod.Dependents != 1 || mod.LatestVersion != "v1.0.0" {
+		t.Errorf("module: %+v", mod)
+	}
+	if mod.Metrics.LoC != 9 || mod.Metrics.TestLoC != 5 {
+		t.Errorf("module metrics: %+v", mod.Metrics)
+	}
+	get(t, router, "/api/v1/module?module=example.com/liba&ref=v1.0.0", http.StatusOK, &mod)
+	if mod.Ref != "v1.0.0" || mod.Commit == "" {
+		t.Errorf("snapshot: %+v", mod)
+	}
+
+	var deps Page[Dependency]
+	get(t, router, "/api/v1/dependencies?module=example.com/svc", http.StatusOK, &deps)
+	if deps.Total != 1 || deps.Items[0].Path != "example.com/liba" || deps.Items[0].Required != "v1.0.0" {
+		t.Errorf("dependencies: %+v", deps)
+	}
+	var dependents Page[Dependent]
+	get(t, router, "/api/v1/dependents?module=example.com/liba", http.StatusOK, &dependents)
+	if dependents.Total != 1 || dependents.Items[0].Path != "example.com/svc" {
+		t.Errorf("dependents: %+v", dependents)
+	}
+	var versions Versions
+	get(t, router, "/api/v1/versions?module=example.com/liba", http.StatusOK, &versions)
+	if versions.Latest != "v1.0.0" {
+		t.Errorf("versions: %+v", versions)
+	}
+
+	var pkg Package
+	get(t, router, "/api/v1/package?module=example.com/liba&package=util", http.StatusOK, &pkg)
+	if pkg.Tests != 1 || len(pkg.Dependents) != 1 || pkg.Dependents[0] != (PackageRef{Module: "example.com/svc", Package: "main"}) {
+		t.Errorf("package: %+v", pkg)
+	}
+	var files Page[FileSummary]
+	get(t, router, "/api/v1/files?module=example.com/liba&package=util", http.StatusOK, &files)
+	if files.Total != 2 || files.Items[0].Name != "util.go" || files.Items[1].Type != "TestGo" {
+		t.Errorf("files: %+v", files)
+	}
+	var file File
+	get(t, router, "/api/v1/file?module=example.com/liba&package=util&file=util.go", http.StatusOK, &file)
+	if len(file.Functions) != 1 || file.Functions[0].Name != "Upper" || file.Functions[0].Complexity != 2 || file.Source != nil {
+		t.Errorf("file: %+v", file)
+	}
+	get(t, router, "/api/v1/file?module=example.com/svc&package=main&file=main.go&source=true", http.StatusOK, &file)
+	if len(file.Source) != 7 || len(file.Imports) != 1 || file.Imports[0].Package != "util" {
+		t.Errorf("file with source: %+v", file)
+	}
+
+	var overview Overview
+	get(t, router, "/api/v1/overview", http.StatusOK, &overview)
+	if overview.LocalModules != 2 || overview.Packages != 2 || overview.LoC != 16 {
+		t.Errorf("overview: %+v", overview)
+	}
+
+	var graph Graph
+	get(t, router, "/api/v1/graph/modules?module=example.com/liba&direction=reverse", http.StatusOK, &graph)
+	if len(graph.Nodes) != 2 || len(graph.Edges) != 1 || graph.Edges[0] != (GraphEdge{From: "example.com/svc", To: "example.com/liba"}) {
+		t.Errorf("module graph: %+v", graph)
+	}
+	get(t, router, "/api/v1/graph/packages?module=example.com/svc&package=main&depth=1", http.StatusOK, &graph)
+	if graph.Root != "example.com/svc#main" || len(graph.Nodes) != 2 || graph.Nodes[0].Package != "util" || graph.Nodes[1].Depth != 0 {
+		t.Errorf("package graph: %+v", graph)
+	}
+
+	for url, status := range map[string]int{
+		"/api/v1/module":                                             http.StatusBadRequest,
+		"/api/v1/module?module=example.com/nope":                     http.StatusNotFound,
+		"/api/v1/module?module=example.com/liba&ref=v9":              http.StatusNotFound,
+		"/api/v1/package?module=example.com/liba&package=x":          http.StatusNotFound,
+		"/api/v1/modules?type=stdlib":                                http.StatusBadRequest,
+		"/api/v1/modules?page=0":                                     http.StatusBadRequest,
+		"/api/v1/modules?per_page=501":                               http.StatusBadRequest,
+		"/api/v1/graph/modules?module=example.com/liba&depth=11":     http.StatusBadRequest,
+		"/api/v1/graph/modules?module=example.com/liba&direction=up": http.StatusBadRequest,
+		"/api/v1/graph/packages?module=example.com/liba":             http.StatusBadRequest,
+		"/api/v1/graph/packages?module=example.com/liba&package=x":   http.StatusNotFound,
+		"/api/v1/nope": http.StatusNotFound,
+	} {
+		var e Error
+		get(t, router, url, status, &e)
+		if e.Error == "" {
+			t.Errorf("GET %s: no error message", url)
+		}
+	}
+}
+
+// TestOpenAPI checks that the OpenAPI document describes exactly the registered routes.
+func TestOpenAPI(t *testing.T) {
+	var doc struct {
+		Paths map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(openapi, &doc); err != nil {
+		t.Fatalf("openapi.json: %v", err)
+	}
+	router := mux.NewRouter()
+	Register(router, nil)
+	routes := make(map[string]bool)
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		tmpl, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		routes[tmpl] = true
+		if _, ok := doc.Paths[tmpl]; !ok {
+			t.Errorf("route %s is missing from openapi.json", tmpl)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for p := range doc.Paths {
+		if !routes[p] {
+			t.Errorf("openapi.json documents %s, which isn't a route", p)
+		}
+	}
+	if !strings.Contains(string(openapi), `"url": "/api/v1"`) {
+		t.Errorf("openapi.json doesn't point at /api/v1")
+	}
+}
@@ -0,0 +1,387 @@
+package apiv1
+
+import (
+	"github.com/perbu/gogrok/analytics"
+	"github.com/perbu/gogrok/history"
+	"math"
+	"sort"
+	"time"
+)
+
+// The types below are the wire format of the v1 API. They only change in backwards
+// compatible ways: fields are added, never renamed or removed. Keep openapi.json in sync.
+
+// Page is one page of a list.
+type Page[T any] struct {
+	Items   []T `json:"items"`
+	Page    int `json:"page"`
+	PerPage int `json:"per_page"`
+	Total   int `json:"total"`
+}
+
+// Error is the body of every response other than 200 OK.
+type Error struct {
+	Error string `json:"error"`
+}
+
+// Metrics are the code metrics of a module, package or file.
+type Metrics struct {
+	LoC        int       `json:"loc"`
+	Files      int       `json:"files"`
+	Complexity float64   `json:"complexity"`
+	TestLoC    int       `json:"test_loc"`
+	Coverage   *Coverage `json:"coverage,omitempty"`
+	Commits    int       `json:"commits"`
+	Churn      int       `json:"churn"`
+	LastCommit string    `json:"last_commit,omitempty"` // RFC 3339
+}
+
+// Coverage is statement coverage from the imported coverage profiles.
+type Coverage struct {
+	Statements int     `json:"statements"`
+	Covered    int     `json:"covered"`
+	Percent    float64 `json:"percent"`
+}
+
+// ModuleSummary is a module in a list.
+type ModuleSummary struct {
+	Path          string   `json:"path"`
+	Type          string   `json:"type"` // local, external or stdlib
+	LatestVersion string   `json:"latest_version,omitempty"`
+	License       string   `json:"license,omitempty"` // SPDX license expression, empty if unknown
+	Metrics       *Metrics `json:"metrics,omitempty"` // local modules and external modules with source
+}
+
+// Module is a module with its counts of related resources.
+type Module struct {
+	ModuleSummary
+	Ref          string   `json:"ref,omitempty"`    // git ref of a snapshot, empty for the working tree
+	Commit       string   `json:"commit,omitempty"` // commit the ref resolved to
+	Packages     int      `json:"packages"`
+	Dependencies int      `json:"dependencies"`
+	Dependents   int      `json:"dependents"`
+	Outdated     int      `json:"outdated_dependencies"`
+	Teams        []string `json:"teams"`
+}
+
+// Dependency is a module required by another module.
+type Dependency struct {
+	Path     string `json:"path"`
+	Type     string `json:"type"`
+	Required string `json:"required"`
+	Latest   string `json:"latest,omitempty"`
+	Outdated bool   `json:"outdated"`
+}
+
+// Dependent is a local module requiring a module.
+type Dependent struct {
+	Path     string `json:"path"`
+	Required string `json:"required"` // the version the dependent requires
+}
+
+// Versions are the known versions of a module, oldest first.
+type Versions struct {
+	Module   string   `json:"module"`
+	Latest   string   `json:"latest,omitempty"`
+	Versions []string `json:"versions"`
+}
+
+// PackageRef identifies a package.
+type PackageRef struct {
+	Module  string `json:"module"`
+	Package string `json:"package"`
+}
+
+// PackageSummary is a package in a list.
+type PackageSummary struct {
+	PackageRef
+	Metrics Metrics `json:"metrics"`
+}
+
+// Package is a package with the local packages importing it. Its files are listed separately.
+type Package struct {
+	PackageSummary
+	Generated  float64      `json:"generated"` // share of generated lines, 0-1
+	Tests      int          `json:"tests"`     // Test, Benchmark, Fuzz and Example functions
+	Teams      []string     `json:"teams"`
+	Dependents []PackageRef `json:"dependents"`
+}
+
+// FileSummary is a file in a list.
+type FileSummary struct {
+	Module     string  `json:"module"`
+	Package    string  `json:"package"`
+	Name       string  `json:"name"`
+	Path       string  `json:"path"` // relative to the module
+	Type       string  `json:"type"` // HumanGo, GeneratedGo or TestGo
+	LoC        int     `json:"loc"`
+	Complexity float64 `json:"complexity"`
+}
+
+// File is a file with its functions and imports, and optionally its source.
+type File struct {
+	FileSummary
+	Metrics   Metrics      `json:"metrics"`
+	Imports   []PackageRef `json:"imports"` // local packages only
+	Functions []Function   `json:"functions"`
+	Teams     []string     `json:"teams"`
+	Source    []string     `json:"source,omitempty"`
+}
+
+// Function is a function or method declared in a file.
+type Function struct {
+	Name       string    `json:"name"` // qualified with the receiver for methods
+	StartLine  int       `json:"start_line"`
+	EndLine    int       `json:"end_line"`
+	Complexity float64   `json:"complexity"`
+	Coverage   *Coverage `json:"coverage,omitempty"`
+}
+
+// Overview sums up all local modules.
+type Overview struct {
+	LocalModules         int     `json:"local_modules"`
+	ExternalModules      int     `json:"external_modules"`
+	Packages             int     `json:"packages"`
+	Files                int     `json:"files"`
+	LoC                  int     `json:"loc"`
+	TestLoC              int     `json:"test_loc"`
+	Complexity           float64 `json:"complexity"` // average over the local modules
+	OutdatedDependencies int     `json:"outdated_dependencies"`
+}
+
+// Graph is the neighbourhood of a module or package in the dependency graph. Edges go from the
+// depending node to its dependency.
+type Graph struct {
+	Root  string      `json:"root"`
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode is a module or package in a graph.
+type GraphNode struct {
+	ID      string `json:"id"`
+	Label   string `json:"label"`
+	Module  string `json:"module"`
+	Package string `json:"package,omitempty"` // local packages in package graphs only
+	Local   bool   `json:"local"`
+	Depth   int    `json:"depth"`             // edges from the root, negative for dependents
+	License string `json:"license,omitempty"` // SPDX license expression of the module
+}
+
+// GraphEdge is a dependency between two nodes, by ID.
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+func depType(t analytics.DepType) string {
+	switch t {
+	case analytics.DepTypeLocal:
+		return "local"
+	case analytics.DepTypeExternal:
+		return "external"
+	case analytics.DepTypeStdlib:
+		return "stdlib"
+	default:
+		return "unknown"
+	}
+}
+
+// number makes a metric safe for JSON, which has no NaN. Averages over nothing are NaN.
+func number(f float32) float64 {
+	if math.IsNaN(float64(f)) || math.IsInf(float64(f), 0) {
+		return 0
+	}
+	return float64(f)
+}
+
+func coverage(c analytics.Coverage) *Coverage {
+	if !c.HasData() {
+		return nil
+	}
+	return &Coverage{Statements: c.Statements, Covered: c.Covered, Percent: number(c.Percent())}
+}
+
+func lastCommit(h *history.Stats) string {
+	if h == nil || h.LastCommit.IsZero() {
+		return ""
+	}
+	return h.LastCommit.UTC().Format(time.RFC3339)
+}
+
+func teams(t []string) []string {
+	if t == nil {
+		return []string{}
+	}
+	return t
+}
+
+func newModuleSummary(m *analytics.Module) ModuleSummary {
+	s := ModuleSummary{Path: m.Path, Type: depType(m.Type), LatestVersion: m.Latest(), License: m.License().Expression()}
+	if m.Type == analytics.DepTypeLocal || m.SourceLoaded() {
+		h := m.History()
+		s.Metrics = &Metrics{
+			LoC:        m.Lines(),
+			Files:      m.Files(),
+			Complexity: number(m.CalculateComplexity()),
+			TestLoC:    m.TestLines(),
+			Coverage:   coverage(m.Coverage()),
+			Commits:    h.Commits(),
+			Churn:      h.Churn(),
+			LastCommit: lastCommit(h),
+		}
+	}
+	return s
+}
+
+func newModule(m *analytics.Module) Module {
+	return Module{
+		ModuleSummary: newModuleSummary(m),
+		Ref:           m.Ref,
+		Commit:        m.Commit,
+		Packages:      len(m.Packages),
+		Dependencies:  len(m.Dependencies),
+		Dependents:    len(m.ReverseModuleDependencies),
+		Outdated:      len(m.OutdatedDependencies()),
+		Teams:         teams(m.Teams()),
+	}
+}
+
+func newDependencies(m *analytics.Module) []Dependency {
+	deps := make([]Dependency, 0, len(m.Dependencies))
+	outdated := make(map[string]bool)
+	for _, o := range m.OutdatedDependencies() {
+		outdated[o.Dependency.Path] = true
+	}
+	for _, dep := range m.Dependencies {
+		deps = append(deps, Dependency{
+			Path:     dep.Path,
+			Type:     depType(dep.Type),
+			Required: m.RequiredVersion(dep),
+			Latest:   dep.Latest(),
+			Outdated: outdated[dep.Path],
+		})
+	}
+	sort.Slice(deps, func(i, j int) bool {
+		return deps[i].Path < deps[j].Path
+	})
+	return deps
+}
+
+func newDependents(m *analytics.Module) []Dependent {
+	deps := make([]Dependent, 0, len(m.ReverseModuleDependencies))
+	for _, dep := range m.ReverseModuleDependencies {
+		deps = append(deps, Dependent{Path: dep.Path, Required: dep.RequiredVersion(m)})
+	}
+	sort.Slice(deps, func(i, j int) bool {
+		return deps[i].Path < deps[j].Path
+	})
+	return deps
+}
+
+func packageRef(p *analytics.Package) PackageRef {
+	return PackageRef{Module: p.Module.Path, Package: p.Name}
+}
+
+func packageRefs(pkgs []*analytics.Package) []PackageRef {
+	refs := make([]PackageRef, 0, len(pkgs))
+	for _, p := range pkgs {
+		refs = append(refs, packageRef(p))
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Module != refs[j].Module {
+			return refs[i].Module < refs[j].Module
+		}
+		return refs[i].Package < refs[j].Package
+	})
+	return refs
+}
+
+func newPackageSummary(p *analytics.Package) PackageSummary {
+	h := p.History()
+	return PackageSummary{
+		PackageRef: packageRef(p),
+		Metrics: Metrics{
+			LoC:        p.Lines(),
+			Files:      p.Files(),
+			Complexity: number(p.CalculateComplexity()),
+			TestLoC:    p.TestLines(),
+			Coverage:   coverage(p.Coverage()),
+			Commits:    h.Commits(),
+			Churn:      h.Churn(),
+			LastCommit: lastCommit(h),
+		},
+	}
+}
+
+func newPackage(p *analytics.Package) Package {
+	return Package{
+		PackageSummary: newPackageSummary(p),
+		Generated:      number(p.Generated()),
+		Tests:          len(p.TestFunctions()),
+		Teams:          teams(p.Teams()),
+		Dependents:     packageRefs(p.ReverseDependencies),
+	}
+}
+
+func newFileSummary(f *analytics.File) FileSummary {
+	return FileSummary{
+		Module:     f.Module.Path,
+		Package:    f.Package.Name,
+		Name:       f.Name,
+		Path:       f.Path,
+		Type:       f.Type.String(),
+		LoC:        f.Lines(),
+		Complexity: number(f.CalculateComplexity()),
+	}
+}
+
+func newFile(f *analytics.File, source bool) File {
+	h := f.History()
+	file := File{
+		FileSummary: newFileSummary(f),
+		Metrics: Metrics{
+			LoC:        f.Lines(),
+			Files:      1,
+			Complexity: number(f.CalculateComplexity()),
+			Coverage:   coverage(f.Coverage()),
+			Commits:    h.Commits(),
+			Churn:      h.Churn(),
+			LastCommit: lastCommit(h),
+		},
+		Imports:   packageRefs(f.Imports),
+		Functions: make([]Function, 0, len(f.Functions())),
+		Teams:     teams(f.Teams()),
+	}
+	if f.Type == analytics.TestGo {
+		file.Metrics.TestLoC = f.Lines()
+	}
+	for _, fn := range f.Functions() {
+		file.Functions = append(file.Functions, Function{
+			Name:       fn.String(),
+			StartLine:  fn.StartLine,
+			EndLine:    fn.EndLine,
+			Complexity: number(fn.CalculateComplexity()),
+			Coverage:   coverage(fn.Coverage()),
+		})
+	}
+	if source {
+		file.Source = f.GetSource()
+	}
+	return file
+}
+
+func newGraph(g *analytics.Graph) Graph {
+	graph := Graph{
+		Root:  g.Root,
+		Nodes: make([]GraphNode, 0, len(g.Nodes)),
+		Edges: make([]GraphEdge, 0, len(g.Edges)),
+	}
+	for _, n := range g.Nodes {
+		graph.Nodes = append(graph.Nodes, GraphNode(n))
+	}
+	for _, e := range g.Edges {
+		graph.Edges = append(graph.Edges, GraphEdge(e))
+	}
+	return graph
+}
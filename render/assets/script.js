@@ -39,8 +39,42 @@ function updateBreadcrumbs(path) {
         currentPage = "Local Modules";
     } else if (normalizedPath === "/external") {
         currentPage = "External Modules";
+    } else if (normalizedPath === "/tests") {
+        currentPage = "Tests";
+    } else if (normalizedPath === "/coverage") {
+        currentPage = "Coverage";
+    } else if (normalizedPath === "/hotspots") {
+        currentPage = "Hotspots";
+    } else if (normalizedPath === "/ownership") {
+        currentPage = "Ownership";
+    } else if (normalizedPath === "/teams") {
+        currentPage = "Teams";
+    } else if (normalizedPath === "/team") {
+        currentPage = "Team Details";
+    } else if (normalizedPath === "/semver") {
+        currentPage = "API Compatibility";
+    } else if (normalizedPath.startsWith("/semver/")) {
+        currentPage = "API Changes";
+    } else if (normalizedPath === "/checksums") {
+        currentPage = "Checksums";
+    } else if (normalizedPath === "/licenses") {
+        currentPage = "Licenses";
+    } else if (normalizedPath === "/stdlib") {
+        currentPage = "Standard Library";
+    } else if (normalizedPath === "/deprecated") {
+        currentPage = "Deprecated API";
+    } else if (normalizedPath === "/risks") {
+        currentPage = "Risky Constructs";
+    } else if (normalizedPath === "/executables") {
+        currentPage = "Executables";
+    } else if (normalizedPath === "/routes") {
+        currentPage = "HTTP Routes";
+    } else if (normalizedPath === "/sync") {
+        currentPage = "Sync";
     } else if (normalizedPath === "/about") {
         currentPage = "About";
+    } else if (normalizedPath.startsWith("/diff/")) {
+        currentPage = "Module Diff";
     } else if (normalizedPath.startsWith("/module/")) {
         currentPage = "Module Details";
     } else if (normalizedPath.startsWith("/package/")) {
@@ -52,6 +86,67 @@ function updateBreadcrumbs(path) {
     breadcrumbCurrent.textContent = currentPage;
 }
 
+// Draw the dependency graphs in swapped-in content. Each .graph element has the JSON API URL
+// of its graph in data-graph-url, and controls adding the depth, direction and local options.
+document.body.addEventListener('htmx:afterSwap', function (evt) {
+    evt.detail.target.querySelectorAll('.graph').forEach(renderGraph);
+});
+
+function renderGraph(el) {
+    if (typeof cytoscape === "undefined") return;
+    const form = el.querySelector('.graph-controls');
+    const canvas = el.querySelector('.graph-canvas');
+    const load = function () {
+        const params = new URLSearchParams(new FormData(form));
+        fetch(el.dataset.graphUrl + '&' + params.toString())
+            .then(resp => resp.json())
+            .then(graph => drawGraph(canvas, graph))
+            .catch(err => console.error("graph", err));
+    };
+    form.addEventListener('change', load);
+    load();
+}
+
+function drawGraph(canvas, graph) {
+    const elements = [];
+    // group the packages of a package graph by module:
+    const modules = new Set();
+    graph.nodes.forEach(n => {
+        if (n.package) modules.add(n.module);
+    });
+    modules.forEach(m => elements.push({data: {id: 'module:' + m, label: m}, classes: 'module'}));
+    graph.nodes.forEach(n => {
+        const data = {id: n.id, label: n.label, module: n.module, package: n.package || ''};
+        if (n.package) data.parent = 'module:' + n.module;
+        const classes = [n.local ? 'local' : 'external'];
+        if (n.id === graph.root) classes.push('root');
+        elements.push({data: data, classes: classes.join(' ')});
+    });
+    graph.edges.forEach(e => elements.push({data: {source: e.from, target: e.to}}));
+
+    const cy = cytoscape({
+        container: canvas,
+        elements: elements,
+        layout: {name: 'breadthfirst', directed: true, roots: [graph.root], spacingFactor: 1.2},
+        style: [
+            {selector: 'node', style: {'label': 'data(label)', 'font-size': 10, 'background-color': '#6c757d'}},
+            {selector: 'node.local', style: {'background-color': '#0d6efd'}},
+            {selector: 'node.root', style: {'background-color': '#dc3545'}},
+            {selector: 'node.module', style: {'background-opacity': 0.05, 'text-valign': 'top'}},
+            {selector: 'edge', style: {'width': 1, 'curve-style': 'bezier', 'target-arrow-shape': 'triangle'}},
+        ],
+    });
+    // click a node to open its module or package:
+    cy.on('tap', 'node', function (evt) {
+        const n = evt.target.data();
+        if (n.package) {
+            htmx.ajax('GET', '/api/package/' + n.module + '?package=' + encodeURIComponent(n.package), '#package');
+        } else if (n.module) {
+            htmx.ajax('GET', '/api/module/' + n.module, '#module');
+        }
+    });
+}
+
 // Initialize breadcrumbs on page load
 document.addEventListener('DOMContentLoaded', function() {
     // Set initial breadcrumb to Dashboard
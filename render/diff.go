@@ -0,0 +1,59 @@
+package render
+
+import (
+	"fmt"
+	"github.com/perbu/gogrok/analytics"
+	"strings"
+)
+
+// diffMarkdown renders a module diff as Markdown, meant to be pasted into release notes.
+func diffMarkdown(d *analytics.ModuleDiff) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## %s: %s → %s\n\n", d.To.Path, d.From.RefLabel(), d.To.RefLabel())
+	if d.Empty() {
+		sb.WriteString("No changes.\n")
+		return sb.String()
+	}
+	if len(d.AddedPackages) > 0 || len(d.RemovedPackages) > 0 {
+		sb.WriteString("### Packages\n\n")
+		for _, name := range d.AddedPackages {
+			fmt.Fprintf(&sb, "- Added `%s`\n", name)
+		}
+		for _, name := range d.RemovedPackages {
+			fmt.Fprintf(&sb, "- Removed `%s`\n", name)
+		}
+		sb.WriteString("\n")
+	}
+	if len(d.Dependencies) > 0 {
+		sb.WriteString("### Dependencies\n\n")
+		for _, dep := range d.Dependencies {
+			switch {
+			case dep.From == "":
+				fmt.Fprintf(&sb, "- Added `%s` %s\n", dep.Path, dep.To)
+			case dep.To == "":
+				fmt.Fprintf(&sb, "- Removed `%s` %s\n", dep.Path, dep.From)
+			default:
+				fmt.Fprintf(&sb, "- Changed `%s` %s → %s\n", dep.Path, dep.From, dep.To)
+			}
+		}
+		sb.WriteString("\n")
+	}
+	if len(d.NewImports) > 0 {
+		sb.WriteString("### New cross-module imports\n\n")
+		for _, ci := range d.NewImports {
+			fmt.Fprintf(&sb, "- `%s` imports `%s`\n", ci.Package, ci.Import)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("### Package metrics\n\n")
+	sb.WriteString("| Package | LoC | Δ LoC | Complexity | Δ Complexity |\n")
+	sb.WriteString("|---|---:|---:|---:|---:|\n")
+	for _, p := range d.Packages {
+		if p.LoCDelta() == 0 && p.ComplexityDelta() == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "| %s | %d | %+d | %.1f | %+.1f |\n",
+			p.Name, p.ToLoC, p.LoCDelta(), p.ToComplexity, p.ComplexityDelta())
+	}
+	return sb.String()
+}
@@ -0,0 +1,38 @@
+package render
+
+import (
+	"github.com/perbu/gogrok/analytics"
+	"strings"
+	"testing"
+)
+
+func TestDiffMarkdown(t *testing.T) {
+	d := &analytics.ModuleDiff{
+		From:          &analytics.Module{Path: "example.com/app", Ref: "v1.0.0"},
+		To:            &analytics.Module{Path: "example.com/app"},
+		AddedPackages: []string{"new"},
+		Dependencies: []analytics.DependencyChange{
+			{Path: "example.com/a", From: "v1.0.0", To: "v1.1.0"},
+			{Path: "example.com/b", From: "v1.0.0"},
+		},
+		Packages: []analytics.PackageDelta{
+			{Name: "new", ToLoC: 10, ToComplexity: 2},
+			{Name: "same", FromLoC: 5, ToLoC: 5, FromComplexity: 1, ToComplexity: 1},
+		},
+	}
+	md := diffMarkdown(d)
+	for _, want := range []string{
+		"## example.com/app: v1.0.0 → working tree",
+		"- Added `new`",
+		"- Changed `example.com/a` v1.0.0 → v1.1.0",
+		"- Removed `example.com/b` v1.0.0",
+		"| new | 10 | +10 | 2.0 | +2.0 |",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown is missing %q:\n%s", want, md)
+		}
+	}
+	if strings.Contains(md, "| same |") {
+		t.Errorf("unchanged packages should be left out:\n%s", md)
+	}
+}
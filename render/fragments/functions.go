@@ -3,8 +3,15 @@ package fragments
 import (
 	"fmt"
 	"github.com/perbu/gogrok/analytics"
+	"github.com/perbu/gogrok/history"
+	"github.com/perbu/gogrok/license"
+	"github.com/perbu/gogrok/reposync"
+	"github.com/perbu/gogrok/sbom"
 	"net/url"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // use templ to generate the Go code:
@@ -26,11 +33,80 @@ func slen[T any](slice []T) string {
 }
 
 func moduleUrl(mod *analytics.Module) string {
-	return fmt.Sprintf("/module/%s", mod.Path)
+	return fmt.Sprintf("/module/%s", moduleRef(mod))
+}
+
+// refUrl returns the URL of a module at a git ref, or the working tree if the ref is empty.
+func refUrl(mod *analytics.Module, ref string) string {
+	if ref == "" {
+		return fmt.Sprintf("/module/%s", mod.Path)
+	}
+	return fmt.Sprintf("/module/%s@%s", mod.Path, ref)
+}
+
+// diffUrl returns the URL of the diff page of a module, the refs are added by the form.
+func diffUrl(mod *analytics.Module) string {
+	return "/api/diff/" + mod.Path
+}
+
+// diffMarkdownUrl returns the URL of the Markdown report of a diff.
+func diffMarkdownUrl(d *analytics.ModuleDiff) string {
+	q := url.Values{}
+	q.Set("from", d.From.Ref)
+	q.Set("to", d.To.Ref)
+	return "/api/diff.md/" + d.To.Path + "?" + q.Encode()
+}
+
+// semverUrl returns the URL of the API compatibility page of a module, with an optional
+// planned next version.
+func semverUrl(mod *analytics.Module, next string) string {
+	if next == "" {
+		return "/api/semver/" + mod.Path
+	}
+	q := url.Values{}
+	q.Set("next", next)
+	return "/api/semver/" + mod.Path + "?" + q.Encode()
+}
+
+// sbomUrl returns the download URL of the SBOM of a module, or of a snapshot of it.
+func sbomUrl(mod *analytics.Module, f sbom.Format) string {
+	return "/api/sbom/" + moduleRef(mod) + "?format=" + string(f)
+}
+
+// moduleGraphUrl returns the JSON API URL of the dependency graph around a module, the graph
+// options are added by the controls.
+func moduleGraphUrl(mod *analytics.Module) string {
+	q := url.Values{}
+	q.Set("module", mod.Path)
+	return "/api/v1/graph/modules?" + q.Encode()
+}
+
+// packageGraphUrl returns the JSON API URL of the import graph around a package.
+func packageGraphUrl(pkg *analytics.Package) string {
+	q := url.Values{}
+	q.Set("module", pkg.Module.Path)
+	q.Set("package", pkg.Name)
+	return "/api/v1/graph/packages?" + q.Encode()
+}
+
+// moduleRef returns the module path, with the ref appended for snapshots, ie. foo@v1.2.0.
+func moduleRef(mod *analytics.Module) string {
+	if mod.Ref == "" {
+		return mod.Path
+	}
+	return mod.Path + "@" + mod.Ref
+}
+
+// shortHash abbreviates a commit hash.
+func shortHash(hash string) string {
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	return hash
 }
 
 func packageUrl(pkg *analytics.Package) string {
-	u, err := url.Parse(fmt.Sprintf("/package/%s", pkg.Module.Path))
+	u, err := url.Parse(fmt.Sprintf("/package/%s", moduleRef(pkg.Module)))
 	if err != nil {
 		panic(err)
 	}
@@ -41,7 +117,7 @@ func packageUrl(pkg *analytics.Package) string {
 }
 
 func fileUrl(file *analytics.File) string {
-	u, err := url.Parse(fmt.Sprintf("/file/%s", file.Module.Path))
+	u, err := url.Parse(fmt.Sprintf("/file/%s", moduleRef(file.Module)))
 	if err != nil {
 		panic(err)
 	}
@@ -52,6 +128,14 @@ func fileUrl(file *analytics.File) string {
 	return u.String()
 }
 
+// teamUrl returns the URL of the team page. Teams are passed as a query parameter since
+// they contain slashes, ie. @org/team.
+func teamUrl(team string) string {
+	q := url.Values{}
+	q.Set("team", team)
+	return "/api/team?" + q.Encode()
+}
+
 // truncateVersion truncates a version string if it's longer than 10 characters
 // and adds "..." at the end
 func truncateVersion(version string) string {
@@ -60,3 +144,155 @@ func truncateVersion(version string) string {
 	}
 	return version
 }
+
+// coverage formats statement coverage as a percentage, or "-" when there is no coverage data.
+func coverage(c analytics.Coverage) string {
+	if !c.HasData() {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", c.Percent())
+}
+
+// lineClass returns the CSS class highlighting the coverage of a source line.
+func lineClass(f *analytics.File, line int) string {
+	switch f.LineCoverage(line) {
+	case analytics.LineCovered:
+		return "cov-covered"
+	case analytics.LineUncovered:
+		return "cov-uncovered"
+	default:
+		return ""
+	}
+}
+
+// syncClass colors a sync status by whether the checkout needs attention.
+func syncClass(r reposync.Result) string {
+	if r.OK() {
+		return "text-green-600"
+	}
+	return "text-red-600"
+}
+
+// syncCommits shows what a sync moved the checkout from and to.
+func syncCommits(r reposync.Result) string {
+	switch {
+	case r.After == "":
+		return "-"
+	case r.Before == "" || r.Before == r.After:
+		return shortHash(r.After)
+	default:
+		return shortHash(r.Before) + ".." + shortHash(r.After)
+	}
+}
+
+// date formats a date, or "-" for the zero time.
+func date(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.DateOnly)
+}
+
+// activity labels code that changes a lot lately, or hasn't changed in a long time.
+func activity(h *history.Stats) string {
+	const (
+		activeWithin = 30 * 24 * time.Hour
+		staleAfter   = 365 * 24 * time.Hour
+	)
+	switch {
+	case h.ChangedWithin(activeWithin):
+		return "(active)"
+	case !h.ChangedWithin(staleAfter):
+		return "(stale)"
+	default:
+		return ""
+	}
+}
+
+// sortedKeys returns the keys of a map in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// joinInts formats numbers as a comma separated list.
+func joinInts(numbers []int) string {
+	s := make([]string, 0, len(numbers))
+	for _, n := range numbers {
+		s = append(s, strconv.Itoa(n))
+	}
+	return strings.Join(s, ", ")
+}
+
+// verdictClass returns the CSS class for rows with a license verdict.
+func verdictClass(v license.Verdict) string {
+	return "license-" + strings.ToLower(v.String())
+}
+
+// licenseText describes the license of a module: the SPDX expression if it was recognized,
+// otherwise what was found.
+func licenseText(i license.Info) string {
+	switch {
+	case len(i.IDs) > 0:
+		return i.Expression()
+	case i.Found():
+		return "not recognized in " + strings.Join(i.Files, ", ")
+	default:
+		return "no license file found"
+	}
+}
+
+// stdlibClass returns the CSS class for the row of a standard library package.
+func stdlibClass(u analytics.StdlibUsage) string {
+	if u.Deprecated != "" {
+		return "stdlib-flagged"
+	}
+	return ""
+}
+
+// deprecatedSymbol returns the deprecated symbol as it is referred to, or the package name if
+// the whole package is deprecated.
+func deprecatedSymbol(u analytics.DeprecatedUse) string {
+	if u.Symbol == "" {
+		return u.Package.Name
+	}
+	return u.Package.Name + "." + u.Symbol
+}
+
+// riskUrl returns the URL of the risky construct inventory, showing only the kind unless it is zero.
+func riskUrl(kind analytics.Risk) string {
+	if kind == 0 {
+		return "/api/risks"
+	}
+	q := url.Values{}
+	q.Set("kind", kind.String())
+	return "/api/risks?" + q.Encode()
+}
+
+// riskFilterClass returns the CSS class for the filter link of a risk kind.
+func riskFilterClass(kind, current analytics.Risk) string {
+	if kind == current {
+		return "risk-filter-active"
+	}
+	return ""
+}
+
+// flagText returns a command line flag as it is given, with its shorthand if it has one.
+func flagText(f analytics.Flag) string {
+	if f.Shorthand == "" {
+		return "-" + f.Name
+	}
+	return fmt.Sprintf("--%s, -%s", f.Name, f.Shorthand)
+}
+
+// routePath returns the host and path of a route, with a * for routes matching a prefix.
+func routePath(rt analytics.Route) string {
+	if rt.Prefix {
+		return rt.Host + rt.Path + "*"
+	}
+	return rt.Host + rt.Path
+}
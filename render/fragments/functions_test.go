@@ -2,6 +2,8 @@ package fragments
 
 import (
 	"github.com/perbu/gogrok/analytics"
+	"github.com/perbu/gogrok/license"
+	"github.com/perbu/gogrok/reposync"
 	"testing"
 )
 
@@ -89,6 +91,14 @@ func TestModuleUrl(t *testing.T) {
 			},
 			expected: "/module/github.com/example/module",
 		},
+		{
+			name: "module snapshot",
+			module: &analytics.Module{
+				Path: "github.com/example/module",
+				Ref:  "v1.2.0",
+			},
+			expected: "/module/github.com/example/module@v1.2.0",
+		},
 	}
 
 	for _, tt := range tests {
@@ -117,6 +127,17 @@ func TestPackageUrl(t *testing.T) {
 			},
 			expected: "/package/github.com/example/module?package=pkg",
 		},
+		{
+			name: "package in a snapshot",
+			pkg: &analytics.Package{
+				Name: "pkg",
+				Module: &analytics.Module{
+					Path: "github.com/example/module",
+					Ref:  "v1.2.0",
+				},
+			},
+			expected: "/package/github.com/example/module@v1.2.0?package=pkg",
+		},
 	}
 
 	for _, tt := range tests {
@@ -159,3 +180,129 @@ func TestFileUrl(t *testing.T) {
 		})
 	}
 }
+
+func TestCoverage(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    analytics.Coverage
+		expected string
+	}{
+		{
+			name:     "no data",
+			input:    analytics.Coverage{},
+			expected: "-",
+		},
+		{
+			name:     "partial coverage",
+			input:    analytics.Coverage{Statements: 8, Covered: 3},
+			expected: "37.5%",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := coverage(tt.input)
+			if result != tt.expected {
+				t.Errorf("coverage(%v) = %v, expected %v", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTeamUrl(t *testing.T) {
+	expected := "/api/team?team=%40org%2Fplatform"
+	if result := teamUrl("@org/platform"); result != expected {
+		t.Errorf("teamUrl(@org/platform) = %v, expected %v", result, expected)
+	}
+}
+
+func TestSyncCommits(t *testing.T) {
+	const a, b = "a4d480bd0123456789", "9f12c3e40123456789"
+	tests := []struct {
+		name     string
+		input    reposync.Result
+		expected string
+	}{
+		{name: "failed clone", input: reposync.Result{}, expected: "-"},
+		{name: "cloned", input: reposync.Result{After: a}, expected: "a4d480bd"},
+		{name: "up to date", input: reposync.Result{Before: a, After: a}, expected: "a4d480bd"},
+		{name: "updated", input: reposync.Result{Before: a, After: b}, expected: "a4d480bd..9f12c3e4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := syncCommits(tt.input)
+			if result != tt.expected {
+				t.Errorf("syncCommits(%v) = %v, expected %v", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestJoinInts(t *testing.T) {
+	if result := joinInts([]int{3, 14, 15}); result != "3, 14, 15" {
+		t.Errorf("joinInts = %v, expected 3, 14, 15", result)
+	}
+	if result := joinInts(nil); result != "" {
+		t.Errorf("joinInts(nil) = %v, expected empty", result)
+	}
+}
+
+func TestLicenseText(t *testing.T) {
+	tests := []struct {
+		input    license.Info
+		expected string
+	}{
+		{license.Info{Files: []string{"LICENSE"}, IDs: []string{"MIT"}}, "MIT"},
+		{license.Info{Files: []string{"COPYING"}}, "not recognized in COPYING"},
+		{license.Info{}, "no license file found"},
+	}
+	for _, tt := range tests {
+		if result := licenseText(tt.input); result != tt.expected {
+			t.Errorf("licenseText(%+v) = %v, expected %v", tt.input, result, tt.expected)
+		}
+	}
+	if result := verdictClass(license.Denied); result != "license-denied" {
+		t.Errorf("verdictClass = %v, expected license-denied", result)
+	}
+}
+
+func TestDeprecatedSymbol(t *testing.T) {
+	pkg := &analytics.Package{Name: "lib"}
+	if result := deprecatedSymbol(analytics.DeprecatedUse{Package: pkg, Symbol: "Config.Name"}); result != "lib.Config.Name" {
+		t.Errorf("deprecatedSymbol = %v, expected lib.Config.Name", result)
+	}
+	if result := deprecatedSymbol(analytics.DeprecatedUse{Package: pkg}); result != "lib" {
+		t.Errorf("deprecatedSymbol = %v, expected lib", result)
+	}
+}
+
+func TestRiskUrl(t *testing.T) {
+	if result := riskUrl(analytics.RiskOsExit); result != "/api/risks?kind=OsExit" {
+		t.Errorf("riskUrl = %v, expected /api/risks?kind=OsExit", result)
+	}
+	if result := riskUrl(0); result != "/api/risks" {
+		t.Errorf("riskUrl(0) = %v, expected /api/risks", result)
+	}
+	if result := riskFilterClass(analytics.RiskCgo, analytics.RiskCgo); result != "risk-filter-active" {
+		t.Errorf("riskFilterClass = %v, expected risk-filter-active", result)
+	}
+}
+
+func TestFlagText(t *testing.T) {
+	if result := flagText(analytics.Flag{Name: "port"}); result != "-port" {
+		t.Errorf("flagText = %v, expected -port", result)
+	}
+	if result := flagText(analytics.Flag{Name: "name", Shorthand: "n"}); result != "--name, -n" {
+		t.Errorf("flagText = %v, expected --name, -n", result)
+	}
+}
+
+func TestRoutePath(t *testing.T) {
+	if result := routePath(analytics.Route{Host: "example.com", Path: "/items/{id}"}); result != "example.com/items/{id}" {
+		t.Errorf("routePath = %v, expected example.com/items/{id}", result)
+	}
+	if result := routePath(analytics.Route{Path: "/static/", Prefix: true}); result != "/static/*" {
+		t.Errorf("routePath = %v, expected /static/*", result)
+	}
+}
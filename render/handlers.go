@@ -1,12 +1,21 @@
 package render
 
 import (
+	"context"
+	"encoding/json"
 	"github.com/gorilla/mux"
 	"github.com/perbu/gogrok/analytics"
+	"github.com/perbu/gogrok/export"
 	"github.com/perbu/gogrok/render/fragments"
+	"github.com/perbu/gogrok/reposync"
+	"github.com/perbu/gogrok/sbom"
+	"io"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
 func notFoundHandler(w http.ResponseWriter, r *http.Request) {
@@ -28,25 +37,27 @@ func makeStaticHandler(path string) http.HandlerFunc {
 }
 
 func (s *Server) handleLocalModuleList(w http.ResponseWriter, r *http.Request) {
+	repo := s.Repo()
 	filter := r.URL.Query().Get("search")
-	mods := s.Repo.ModuleFilter(analytics.DepTypeLocal, filter)
+	team := r.URL.Query().Get("team")
+	mods := repo.ModuleFilter(analytics.DepTypeLocal, filter)
 	filteredMods := make([]analytics.Module, 0)
 	for _, mod := range mods {
-		if strings.Contains(mod.Path, filter) {
+		if strings.Contains(mod.Path, filter) && (team == "" || mod.OwnedBy(team)) {
 			filteredMods = append(filteredMods, mod)
 		}
 	}
-	err := fragments.LocalModules(filteredMods).Render(r.Context(), w)
+	err := fragments.LocalModules(filteredMods, repo.Teams(), team).Render(r.Context(), w)
 	if err != nil {
 		slog.Error("templ Render", "fragment", "localModules",
-			"filter", filter, "error", err)
+			"filter", filter, "team", team, "error", err)
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 	}
 }
 
 func (s *Server) handleExternalModuleList(w http.ResponseWriter, r *http.Request) {
 	filter := r.URL.Query().Get("search")
-	mods := s.Repo.ModuleFilter(analytics.DepTypeExternal, filter)
+	mods := s.Repo().ModuleFilter(analytics.DepTypeExternal, filter)
 	filteredMods := make([]analytics.Module, 0)
 	for _, mod := range mods {
 		if strings.Contains(mod.Path, filter) {
@@ -70,10 +81,89 @@ func (s *Server) handleAbout(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (s *Server) handleTests(w http.ResponseWriter, r *http.Request) {
+	repo := s.Repo()
+	mods := repo.ModuleFilter(analytics.DepTypeLocal, "")
+	err := fragments.Tests(mods, repo.UntestedPackages()).Render(r.Context(), w)
+	if err != nil {
+		slog.Error("templ Render", "fragment", "tests", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleCoverage(w http.ResponseWriter, r *http.Request) {
+	repo := s.Repo()
+	const riskiestFunctions = 50
+	mods := repo.ModuleFilter(analytics.DepTypeLocal, "")
+	err := fragments.Coverage(mods, repo.RiskiestFunctions(riskiestFunctions)).Render(r.Context(), w)
+	if err != nil {
+		slog.Error("templ Render", "fragment", "coverage", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleHotspots(w http.ResponseWriter, r *http.Request) {
+	const maxHotspots = 100
+	hotspots := s.Repo().Hotspots()
+	if len(hotspots) > maxHotspots {
+		hotspots = hotspots[:maxHotspots]
+	}
+	err := fragments.Hotspots(hotspots).Render(r.Context(), w)
+	if err != nil {
+		slog.Error("templ Render", "fragment", "hotspots", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleHotspotsJSON(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(hotspotTree(s.Repo().Hotspots()))
+	if err != nil {
+		slog.Error("json Encode", "endpoint", "hotspots", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleOwnership(w http.ResponseWriter, r *http.Request) {
+	err := fragments.Ownership(s.Repo().KnowledgeRisks()).Render(r.Context(), w)
+	if err != nil {
+		slog.Error("templ Render", "fragment", "ownership", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleTeams(w http.ResponseWriter, r *http.Request) {
+	repo := s.Repo()
+	teams := make([]analytics.TeamSummary, 0)
+	for _, team := range repo.Teams() {
+		teams = append(teams, repo.TeamSummary(team))
+	}
+	err := fragments.Teams(teams).Render(r.Context(), w)
+	if err != nil {
+		slog.Error("templ Render", "fragment", "teams", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleTeam(w http.ResponseWriter, r *http.Request) {
+	repo := s.Repo()
+	team := r.URL.Query().Get("team")
+	if !slices.Contains(repo.Teams(), team) {
+		http.Error(w, "team not found", http.StatusNotFound)
+		return
+	}
+	err := fragments.Team(repo.TeamSummary(team)).Render(r.Context(), w)
+	if err != nil {
+		slog.Error("templ Render", "fragment", "team", "team", team, "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
 func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	repo := s.Repo()
 	// Get counts for dashboard widgets
-	localModules := s.Repo.ModuleFilter(analytics.DepTypeLocal, "")
-	externalModules := s.Repo.ModuleFilter(analytics.DepTypeExternal, "")
+	localModules := repo.ModuleFilter(analytics.DepTypeLocal, "")
+	externalModules := repo.ModuleFilter(analytics.DepTypeExternal, "")
 
 	// Calculate total lines of code for local modules
 	totalLoc := 0
@@ -81,6 +171,11 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 		totalLoc += mod.Lines()
 	}
 
+	outdatedDeps := 0
+	for _, mod := range localModules {
+		outdatedDeps += len(mod.OutdatedDependencies())
+	}
+
 	// Calculate average complexity for local modules
 	totalComplexity := float32(0)
 	moduleCount := len(localModules)
@@ -99,7 +194,7 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 		"TotalLoc":              totalLoc,
 		"AvgComplexity":         avgComplexity,
 		"SecurityIssuesCount":   0, // Placeholder for future implementation
-		"OutdatedDepsCount":     0, // Placeholder for future implementation
+		"OutdatedDepsCount":     outdatedDeps,
 	}
 
 	// Render dashboard template
@@ -110,12 +205,255 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (s *Server) handleSemver(w http.ResponseWriter, r *http.Request) {
+	err := fragments.Semver(s.Repo().SemverReports()).Render(r.Context(), w)
+	if err != nil {
+		slog.Error("templ Render", "fragment", "semver", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleChecksums(w http.ResponseWriter, r *http.Request) {
+	repo := s.Repo()
+	err := fragments.Checksums(repo.SumConflicts(), repo.SumChecks()).Render(r.Context(), w)
+	if err != nil {
+		slog.Error("templ Render", "fragment", "checksums", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleLicenses(w http.ResponseWriter, r *http.Request) {
+	err := fragments.Licenses(s.Repo().Licenses()).Render(r.Context(), w)
+	if err != nil {
+		slog.Error("templ Render", "fragment", "licenses", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleStdlib(w http.ResponseWriter, r *http.Request) {
+	err := fragments.Stdlib(s.Repo().StdlibUsage()).Render(r.Context(), w)
+	if err != nil {
+		slog.Error("templ Render", "fragment", "stdlib", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleDeprecated(w http.ResponseWriter, r *http.Request) {
+	err := fragments.Deprecated(s.Repo().DeprecationReports()).Render(r.Context(), w)
+	if err != nil {
+		slog.Error("templ Render", "fragment", "deprecated", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleRisks(w http.ResponseWriter, r *http.Request) {
+	var kind analytics.Risk
+	if name := r.URL.Query().Get("kind"); name != "" {
+		var ok bool
+		if kind, ok = analytics.ParseRisk(name); !ok {
+			http.Error(w, "unknown kind of risk", http.StatusBadRequest)
+			return
+		}
+	}
+	err := fragments.RiskInventory(s.Repo().RiskInventory(kind), kind).Render(r.Context(), w)
+	if err != nil {
+		slog.Error("templ Render", "fragment", "risks", "kind", kind, "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleExecutables(w http.ResponseWriter, r *http.Request) {
+	repo := s.Repo()
+	err := fragments.Executables(repo.Binaries(), repo.LinkedLibraries()).Render(r.Context(), w)
+	if err != nil {
+		slog.Error("templ Render", "fragment", "executables", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleRoutes(w http.ResponseWriter, r *http.Request) {
+	search := r.URL.Query().Get("search")
+	err := fragments.RouteCatalog(s.Repo().RouteCatalog(search), search).Render(r.Context(), w)
+	if err != nil {
+		slog.Error("templ Render", "fragment", "routes", "search", search, "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleSemverModule(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["module"]
+	next := r.URL.Query().Get("next")
+	report, err := s.Repo().SemverCheck(name, next)
+	if err != nil {
+		slog.Warn("semver check", "module", name, "next", next, "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = fragments.SemverModule(report).Render(r.Context(), w)
+	if err != nil {
+		slog.Error("templ Render", "fragment", "semverModule", "module", name, "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleSync(w http.ResponseWriter, r *http.Request) {
+	if s.syncer == nil {
+		http.Error(w, "sync not configured", http.StatusNotFound)
+		return
+	}
+	results, lastRun := s.syncer.Last()
+	s.renderSync(w, r, results, lastRun, nil)
+}
+
+// handleSyncRun syncs the repositories and shows the outcome. The sync isn't cancelled if the
+// client goes away, a clone cut short is thrown away and would have to start over.
+func (s *Server) handleSyncRun(w http.ResponseWriter, r *http.Request) {
+	if s.syncer == nil {
+		http.Error(w, "sync not configured", http.StatusNotFound)
+		return
+	}
+	_, err := s.syncer.Run(context.WithoutCancel(r.Context()))
+	if err != nil {
+		slog.Warn("repository sync", "manifest", s.syncer.Manifest, "error", err)
+	}
+	results, lastRun := s.syncer.Last()
+	s.renderSync(w, r, results, lastRun, err)
+}
+
+func (s *Server) renderSync(w http.ResponseWriter, r *http.Request, results []reposync.Result, lastRun time.Time, syncErr error) {
+	err := fragments.Sync(s.syncer.Manifest, results, lastRun, syncErr).Render(r.Context(), w)
+	if err != nil {
+		slog.Error("templ Render", "fragment", "sync", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleDiff(w http.ResponseWriter, r *http.Request) {
+	d, ok := s.lookupDiff(w, r)
+	if !ok {
+		return
+	}
+	err := fragments.Diff(d).Render(r.Context(), w)
+	if err != nil {
+		slog.Error("templ Render", "fragment", "diff", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleDiffMarkdown(w http.ResponseWriter, r *http.Request) {
+	d, ok := s.lookupDiff(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	_, err := io.WriteString(w, diffMarkdown(d))
+	if err != nil {
+		slog.Error("write", "endpoint", "diff.md", "error", err)
+	}
+}
+
+// handleExport downloads the module or package graph in the format of the format parameter,
+// limited by the prefix, local and depth parameters.
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	format, err := export.ParseFormat(q.Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var o export.Options
+	switch q.Get("graph") {
+	case "modules", "":
+	case "packages":
+		o.Packages = true
+	default:
+		http.Error(w, "graph must be modules or packages", http.StatusBadRequest)
+		return
+	}
+	o.Prefix = q.Get("prefix")
+	o.LocalOnly, _ = strconv.ParseBool(q.Get("local"))
+	if d := q.Get("depth"); d != "" {
+		o.Depth, err = strconv.Atoi(d)
+		if err != nil || o.Depth < 0 {
+			http.Error(w, "depth must be a number, 0 for no limit", http.StatusBadRequest)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", `attachment; filename="`+export.Filename(o, format)+`"`)
+	err = export.Write(w, export.Graph(s.Repo(), o), format)
+	if err != nil {
+		slog.Error("export.Write", "format", format, "error", err)
+	}
+}
+
+// handleSBOM downloads the software bill of materials of a local module, in the format of the
+// format parameter.
+func (s *Server) handleSBOM(w http.ResponseWriter, r *http.Request) {
+	format, err := sbom.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	mod, ok := s.lookupModule(r)
+	if !ok || mod.Type != analytics.DepTypeLocal {
+		http.Error(w, "local module not found", http.StatusNotFound)
+		return
+	}
+	doc, err := sbom.New(mod)
+	if err != nil {
+		slog.Error("sbom.New", "module", mod.Path, "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", `attachment; filename="`+sbom.Filename(mod.Path, format)+`"`)
+	err = doc.Write(w, format)
+	if err != nil {
+		slog.Error("doc.Write", "format", format, "error", err)
+	}
+}
+
+// lookupDiff diffs the module in the URL between the from and to query parameters, an empty
+// ref being the working tree. It writes an error response if that fails.
+func (s *Server) lookupDiff(w http.ResponseWriter, r *http.Request) (*analytics.ModuleDiff, bool) {
+	name := mux.Vars(r)["module"]
+	from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+	slog.Info("handleDiff", "module", name, "from", from, "to", to)
+	d, err := s.Repo().DiffModule(name, from, to)
+	if err != nil {
+		slog.Warn("module diff", "module", name, "from", from, "to", to, "error", err)
+		http.Error(w, "module or ref not found", http.StatusNotFound)
+		return nil, false
+	}
+	return d, true
+}
+
+// lookupModule finds the module named in the URL. A module can be requested at a git ref,
+// either as module@ref or with a ref query parameter.
+func (s *Server) lookupModule(request *http.Request) (*analytics.Module, bool) {
+	repo := s.Repo()
+	name, ref, _ := strings.Cut(mux.Vars(request)["module"], "@")
+	if ref == "" {
+		ref = request.URL.Query().Get("ref")
+	}
+	if ref == "" {
+		return repo.GetModule(name)
+	}
+	mod, err := repo.ModuleAt(name, ref)
+	if err != nil {
+		slog.Warn("module snapshot", "module", name, "ref", ref, "error", err)
+		return nil, false
+	}
+	return mod, true
+}
+
 func (s *Server) handleModule(writer http.ResponseWriter, request *http.Request) {
 	// get the module name from the URL
 	vars := mux.Vars(request)
 	moduleName := vars["module"]
 	slog.Info("handleModule", "module", moduleName)
-	mod, ok := s.Repo.GetModule(moduleName)
+	mod, ok := s.lookupModule(request)
 	if !ok {
 		http.Error(writer, "module not found", http.StatusNotFound)
 		return
@@ -132,7 +470,7 @@ func (s *Server) handlePackage(writer http.ResponseWriter, request *http.Request
 	moduleName := vars["module"]
 	packageName := request.URL.Query().Get("package")
 	slog.Info("handlePackage", "module", moduleName, "package", packageName)
-	mod, ok := s.Repo.GetModule(moduleName)
+	mod, ok := s.lookupModule(request)
 	if !ok {
 		http.Error(writer, "module not found", http.StatusNotFound)
 		return
@@ -164,7 +502,7 @@ func (s *Server) handleFile(writer http.ResponseWriter, request *http.Request) {
 	fileName := request.URL.Query().Get("file")
 	slog.Info("handleFile", "module", moduleName, "package", packageName, "file", fileName)
 	// find the module in the repo:
-	mod, ok := s.Repo.GetModule(moduleName)
+	mod, ok := s.lookupModule(request)
 	if !ok {
 		http.Error(writer, "module not found", http.StatusNotFound)
 		return
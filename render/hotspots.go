@@ -0,0 +1,48 @@
+package render
+
+import (
+	"github.com/perbu/gogrok/analytics"
+	"sort"
+)
+
+// treemapNode is a node in the hotspot hierarchy, module > package > file, in the shape
+// d3-hierarchy and similar treemap libraries expect. Leaves are sized by lines of code
+// and colored by score.
+type treemapNode struct {
+	Name       string         `json:"name"`
+	Children   []*treemapNode `json:"children,omitempty"`
+	Value      int            `json:"value,omitempty"`
+	Score      float32        `json:"score,omitempty"`
+	Commits    int            `json:"commits,omitempty"`
+	Complexity float32        `json:"complexity,omitempty"`
+}
+
+func (n *treemapNode) child(name string) *treemapNode {
+	for _, c := range n.Children {
+		if c.Name == name {
+			return c
+		}
+	}
+	c := &treemapNode{Name: name}
+	n.Children = append(n.Children, c)
+	return c
+}
+
+// hotspotTree arranges the hotspots into a module > package > file tree.
+func hotspotTree(hotspots []analytics.Hotspot) *treemapNode {
+	root := &treemapNode{Name: "hotspots"}
+	for _, h := range hotspots {
+		pkg := root.child(h.File.Module.Path).child(h.File.Package.Name)
+		pkg.Children = append(pkg.Children, &treemapNode{
+			Name:       h.File.Name,
+			Value:      h.File.Lines(),
+			Score:      h.Score,
+			Commits:    h.Commits,
+			Complexity: h.Complexity,
+		})
+	}
+	sort.Slice(root.Children, func(i, j int) bool {
+		return root.Children[i].Name < root.Children[j].Name
+	})
+	return root
+}
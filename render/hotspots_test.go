@@ -0,0 +1,26 @@
+package render
+
+import (
+	"github.com/perbu/gogrok/analytics"
+	"testing"
+)
+
+func TestHotspotTree(t *testing.T) {
+	mod := &analytics.Module{Path: "example.com/mod"}
+	pkg := &analytics.Package{Name: "pkg", Module: mod}
+	hotspots := []analytics.Hotspot{
+		{File: &analytics.File{Name: "a.go", Package: pkg, Module: mod}, Score: 1, Commits: 4},
+		{File: &analytics.File{Name: "b.go", Package: pkg, Module: mod}, Score: 0.5, Commits: 2},
+	}
+	root := hotspotTree(hotspots)
+	if len(root.Children) != 1 || root.Children[0].Name != "example.com/mod" {
+		t.Fatalf("expected a single module node, got %+v", root.Children)
+	}
+	pkgs := root.Children[0].Children
+	if len(pkgs) != 1 || pkgs[0].Name != "pkg" {
+		t.Fatalf("expected a single package node, got %+v", pkgs)
+	}
+	if len(pkgs[0].Children) != 2 || pkgs[0].Children[0].Commits != 4 {
+		t.Errorf("expected two file leaves in score order, got %+v", pkgs[0].Children)
+	}
+}
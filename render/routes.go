@@ -2,6 +2,7 @@ package render
 
 import (
 	"github.com/gorilla/mux"
+	"github.com/perbu/gogrok/render/apiv1"
 	"log/slog"
 	"net/http"
 	"time"
@@ -16,11 +17,36 @@ func makeMux(s *Server) *mux.Router {
 	// Create an API subrouter for fragment content
 	api := gmux.PathPrefix("/api").Subrouter()
 
+	// The JSON API, mounted before the fragment routes so they don't shadow it:
+	apiv1.Register(api.PathPrefix("/v1").Subrouter(), s.Repo)
+
 	// Add fragment content routes to the API subrouter
 	api.HandleFunc("/dashboard", s.handleDashboard).Methods(http.MethodGet)
 	api.HandleFunc("/local", s.handleLocalModuleList).Methods(http.MethodGet)
 	api.HandleFunc("/external", s.handleExternalModuleList).Methods(http.MethodGet)
 	api.HandleFunc("/about", s.handleAbout).Methods(http.MethodGet)
+	api.HandleFunc("/tests", s.handleTests).Methods(http.MethodGet)
+	api.HandleFunc("/coverage", s.handleCoverage).Methods(http.MethodGet)
+	api.HandleFunc("/hotspots", s.handleHotspots).Methods(http.MethodGet)
+	api.HandleFunc("/hotspots.json", s.handleHotspotsJSON).Methods(http.MethodGet)
+	api.HandleFunc("/ownership", s.handleOwnership).Methods(http.MethodGet)
+	api.HandleFunc("/teams", s.handleTeams).Methods(http.MethodGet)
+	api.HandleFunc("/team", s.handleTeam).Methods(http.MethodGet)
+	api.HandleFunc("/semver", s.handleSemver).Methods(http.MethodGet)
+	api.HandleFunc("/semver/{module:.*}", s.handleSemverModule).Methods(http.MethodGet)
+	api.HandleFunc("/checksums", s.handleChecksums).Methods(http.MethodGet)
+	api.HandleFunc("/licenses", s.handleLicenses).Methods(http.MethodGet)
+	api.HandleFunc("/stdlib", s.handleStdlib).Methods(http.MethodGet)
+	api.HandleFunc("/deprecated", s.handleDeprecated).Methods(http.MethodGet)
+	api.HandleFunc("/risks", s.handleRisks).Methods(http.MethodGet)
+	api.HandleFunc("/executables", s.handleExecutables).Methods(http.MethodGet)
+	api.HandleFunc("/routes", s.handleRoutes).Methods(http.MethodGet)
+	api.HandleFunc("/sync", s.handleSync).Methods(http.MethodGet)
+	api.HandleFunc("/sync", s.handleSyncRun).Methods(http.MethodPost)
+	api.HandleFunc("/diff/{module:.*}", s.handleDiff).Methods(http.MethodGet)
+	api.HandleFunc("/diff.md/{module:.*}", s.handleDiffMarkdown).Methods(http.MethodGet)
+	api.HandleFunc("/export", s.handleExport).Methods(http.MethodGet)
+	api.HandleFunc("/sbom/{module:.*}", s.handleSBOM).Methods(http.MethodGet)
 	api.HandleFunc("/module/{module:.*}", s.handleModule).Methods(http.MethodGet)
 	api.HandleFunc("/package/{module:[^?]*}", s.handlePackage).Methods(http.MethodGet)
 	api.HandleFunc("/file/{module:[^?]*}", s.handleFile).Methods(http.MethodGet)
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/perbu/gogrok/analytics"
+	"github.com/perbu/gogrok/reposync"
 	"html/template"
 	"io/fs"
 	"log/slog"
@@ -13,13 +14,15 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
 )
 
 //go:embed assets/*
 var assets embed.FS
 
 type Server struct {
-	Repo      *analytics.Repo
+	repo      atomic.Pointer[analytics.Repo]
+	syncer    *reposync.Syncer
 	srv       *http.Server
 	templates *template.Template
 	logger    *slog.Logger
@@ -28,9 +31,9 @@ type Server struct {
 func New(repo *analytics.Repo, logger *slog.Logger) (*Server, error) {
 	const defaultPort = 8080
 	s := &Server{
-		Repo:   repo,
 		logger: logger.WithGroup("webserver"),
 	}
+	s.repo.Store(repo)
 	r := loggingMiddleware(makeMux(s))
 	addr := fmt.Sprintf(":%d", getEnvInt("PORT", defaultPort))
 	srv := &http.Server{
@@ -47,6 +50,21 @@ func New(repo *analytics.Repo, logger *slog.Logger) (*Server, error) {
 	return s, nil
 }
 
+// Repo returns the analysis currently served.
+func (s *Server) Repo() *analytics.Repo {
+	return s.repo.Load()
+}
+
+// SetRepo replaces the analysis served. Requests in flight finish with the one they started with.
+func (s *Server) SetRepo(repo *analytics.Repo) {
+	s.repo.Store(repo)
+}
+
+// SetSyncer enables the Sync page. It must be called before Start.
+func (s *Server) SetSyncer(syncer *reposync.Syncer) {
+	s.syncer = syncer
+}
+
 func (s *Server) Start(ctx context.Context) error {
 	go func() {
 		<-ctx.Done()
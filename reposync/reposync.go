@@ -0,0 +1,263 @@
+package reposync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Manifest lists the repositories that make up code/.
+type Manifest struct {
+	Repos []Repo `json:"repos"`
+}
+
+// Repo is a repository in the manifest.
+type Repo struct {
+	URL    string `json:"url"`
+	Branch string `json:"branch,omitempty"` // defaults to the branch the remote has checked out
+	Dir    string `json:"dir,omitempty"`    // directory of the checkout, defaults to the last element of the URL
+}
+
+// LoadManifest reads a JSON manifest like
+//
+//	{"repos": [{"url": "git@github.com:org/svc.git", "branch": "main"}]}
+//
+// and fills in the directory of each repository.
+func LoadManifest(name string) (*Manifest, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("os.ReadFile: %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	seen := make(map[string]string)
+	for i := range m.Repos {
+		r := &m.Repos[i]
+		if r.URL == "" {
+			return nil, fmt.Errorf("repo %d: url missing", i)
+		}
+		if r.Dir == "" {
+			r.Dir = dirFromURL(r.URL)
+		}
+		if !fs.ValidPath(r.Dir) || strings.Contains(r.Dir, "/") || r.Dir == "." {
+			return nil, fmt.Errorf("repo %s: %q is not a valid directory name", r.URL, r.Dir)
+		}
+		if other, ok := seen[r.Dir]; ok {
+			return nil, fmt.Errorf("repos %s and %s both check out into %s", other, r.URL, r.Dir)
+		}
+		seen[r.Dir] = r.URL
+	}
+	return &m, nil
+}
+
+// dirFromURL returns the last element of a git URL without the .git suffix, so both
+// git@github.com:org/svc.git and file:///srv/git/svc give svc.
+func dirFromURL(url string) string {
+	url = strings.TrimSuffix(strings.TrimRight(url, "/"), ".git")
+	if i := strings.LastIndexAny(url, "/:"); i >= 0 {
+		url = url[i+1:]
+	}
+	return url
+}
+
+//go:generate go run golang.org/x/tools/cmd/stringer@latest -type=Status -trimprefix=Status
+
+// Status is the outcome of syncing a repository.
+type Status int
+
+const (
+	StatusOK          Status = iota // up to date, or ahead of the remote
+	StatusCloned                    // cloned, it wasn't checked out before
+	StatusUpdated                   // fast-forwarded to the remote branch
+	StatusDiverged                  // local and remote branches both have commits the other lacks
+	StatusDirty                     // fetched, but not fast-forwarded because of local changes
+	StatusAuthFailure               // the remote refused our credentials, or we had none
+	StatusFailed                    // anything else
+)
+
+// Result is the outcome of syncing one repository.
+type Result struct {
+	Repo   Repo
+	Status Status
+	Before string // commit checked out before the sync, empty if the repo was cloned
+	After  string // commit checked out after the sync
+	Note   string // details, like why a sync failed
+}
+
+// OK returns true if the checkout is in sync with the remote.
+func (r Result) OK() bool {
+	return r.Status == StatusOK || r.Status == StatusCloned || r.Status == StatusUpdated
+}
+
+// Sync clones the repositories of the manifest missing from dir and fetches and fast-forwards
+// the rest, running at most parallel git operations at a time. Results are in manifest order.
+func Sync(ctx context.Context, dir string, m *Manifest, parallel int) []Result {
+	parallel = max(parallel, 1)
+	results := make([]Result, len(m.Repos))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, repo := range m.Repos {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i] = failed(repo, ctx.Err())
+				return
+			}
+			start := time.Now()
+			results[i] = syncRepo(ctx, filepath.Join(dir, repo.Dir), repo)
+			slog.Info("synced repository", "dir", repo.Dir, "status", results[i].Status,
+				"note", results[i].Note, "duration", time.Since(start))
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func syncRepo(ctx context.Context, target string, r Repo) Result {
+	_, err := os.Stat(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return clone(ctx, target, r)
+	}
+	if err != nil {
+		return failed(r, err)
+	}
+	return update(ctx, target, r)
+}
+
+func clone(ctx context.Context, target string, r Repo) Result {
+	opts := &git.CloneOptions{URL: r.URL, Tags: git.AllTags}
+	if r.Branch != "" {
+		opts.ReferenceName = plumbing.NewBranchReferenceName(r.Branch)
+	}
+	repo, err := git.PlainCloneContext(ctx, target, false, opts)
+	if err != nil {
+		// don't leave a half-done clone behind, the next sync would take it for a checkout:
+		_ = os.RemoveAll(target)
+		return failed(r, err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return failed(r, fmt.Errorf("repo.Head: %w", err))
+	}
+	return Result{Repo: r, Status: StatusCloned, After: head.Hash().String()}
+}
+
+func update(ctx context.Context, target string, r Repo) Result {
+	repo, err := git.PlainOpen(target)
+	if err != nil {
+		return failed(r, fmt.Errorf("git.PlainOpen: %w", err))
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return failed(r, fmt.Errorf("repo.Head: %w", err))
+	}
+	res := Result{Repo: r, Before: head.Hash().String(), After: head.Hash().String()}
+	if !head.Name().IsBranch() {
+		res.Status, res.Note = StatusFailed, "HEAD is detached"
+		return res
+	}
+	branch := head.Name().Short()
+	if r.Branch != "" && r.Branch != branch {
+		res.Status, res.Note = StatusFailed, fmt.Sprintf("on branch %s, the manifest wants %s", branch, r.Branch)
+		return res
+	}
+	err = repo.FetchContext(ctx, &git.FetchOptions{RemoteName: git.DefaultRemoteName, Tags: git.AllTags})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		res.Status, res.Note = classify(err), err.Error()
+		return res
+	}
+	remote, err := repo.Reference(plumbing.NewRemoteReferenceName(git.DefaultRemoteName, branch), true)
+	if err != nil {
+		res.Status, res.Note = StatusFailed, fmt.Sprintf("no branch %s on %s", branch, git.DefaultRemoteName)
+		return res
+	}
+	if remote.Hash() == head.Hash() {
+		res.Status = StatusOK
+		return res
+	}
+	local, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return failed(r, fmt.Errorf("repo.CommitObject: %w", err))
+	}
+	upstream, err := repo.CommitObject(remote.Hash())
+	if err != nil {
+		return failed(r, fmt.Errorf("repo.CommitObject: %w", err))
+	}
+	if ahead, err := upstream.IsAncestor(local); err == nil && ahead {
+		res.Status, res.Note = StatusOK, "ahead of "+git.DefaultRemoteName
+		return res
+	}
+	if behind, err := local.IsAncestor(upstream); err != nil || !behind {
+		res.Status = StatusDiverged
+		return res
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return failed(r, fmt.Errorf("repo.Worktree: %w", err))
+	}
+	dirty, err := modified(wt)
+	if err != nil {
+		return failed(r, err)
+	}
+	if dirty {
+		res.Status, res.Note = StatusDirty, "local changes, not fast-forwarded"
+		return res
+	}
+	// resetting moves the checked out branch along with the worktree:
+	err = wt.Reset(&git.ResetOptions{Commit: remote.Hash(), Mode: git.MergeReset})
+	if err != nil {
+		return failed(r, fmt.Errorf("wt.Reset: %w", err))
+	}
+	res.Status, res.After = StatusUpdated, remote.Hash().String()
+	return res
+}
+
+// modified returns true if tracked files in the worktree have changes. Untracked files don't
+// get in the way of a fast-forward, so they don't count.
+func modified(wt *git.Worktree) (bool, error) {
+	status, err := wt.Status()
+	if err != nil {
+		return false, fmt.Errorf("wt.Status: %w", err)
+	}
+	for _, s := range status {
+		if s.Worktree == git.Untracked || (s.Worktree == git.Unmodified && s.Staging == git.Unmodified) {
+			continue
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+func failed(r Repo, err error) Result {
+	return Result{Repo: r, Status: classify(err), Note: err.Error()}
+}
+
+// classify tells authentication failures from other errors. The ssh transport doesn't wrap
+// its errors, so those are recognized by their message.
+func classify(err error) Status {
+	if errors.Is(err, transport.ErrAuthenticationRequired) || errors.Is(err, transport.ErrAuthorizationFailed) {
+		return StatusAuthFailure
+	}
+	msg := err.Error()
+	if strings.Contains(msg, "unable to authenticate") || strings.Contains(msg, "SSH_AUTH_SOCK") {
+		return StatusAuthFailure
+	}
+	return StatusFailed
+}
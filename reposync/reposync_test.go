@@ -0,0 +1,175 @@
+package reposync
+
+import (
+	"context"
+	"errors"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// commitFile writes a file in the worktree of repo and commits it.
+func commitFile(t *testing.T, repo *git.Repository, name, content string) {
+	t.Helper()
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(wt.Filesystem.Root(), name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.Add(name); err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "dev", Email: "dev@example.com", When: time.Now()}
+	if _, err := wt.Commit("update "+name, &git.CommitOptions{Author: sig}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeManifest(t *testing.T, content string) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "repos.json")
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestLoadManifest(t *testing.T) {
+	m, err := LoadManifest(writeManifest(t, `{"repos": [
+		{"url": "git@github.com:org/svc.git", "branch": "main"},
+		{"url": "file:///srv/git/lib/"},
+		{"url": "https://example.com/org/tool.git", "dir": "tools"}
+	]}`))
+	if err != nil {
+		t.Fatalf("LoadManifest: %v", err)
+	}
+	want := []string{"svc", "lib", "tools"}
+	for i, r := range m.Repos {
+		if r.Dir != want[i] {
+			t.Errorf("repo %s: dir %q, want %q", r.URL, r.Dir, want[i])
+		}
+	}
+
+	bad := map[string]string{
+		"no url":      `{"repos": [{"branch": "main"}]}`,
+		"same dir":    `{"repos": [{"url": "git@a.com:x/svc.git"}, {"url": "git@b.com:y/svc"}]}`,
+		"escapes":     `{"repos": [{"url": "git@a.com:x/svc.git", "dir": "../svc"}]}`,
+		"nested":      `{"repos": [{"url": "git@a.com:x/svc.git", "dir": "x/svc"}]}`,
+		"not json":    `repos: []`,
+		"wrong types": `{"repos": {"url": "git@a.com:x/svc.git"}}`,
+	}
+	for name, content := range bad {
+		if _, err := LoadManifest(writeManifest(t, content)); err == nil {
+			t.Errorf("%s: LoadManifest returned no error", name)
+		}
+	}
+}
+
+func TestSync(t *testing.T) {
+	ctx := context.Background()
+	upstreamDir := filepath.Join(t.TempDir(), "lib")
+	upstream, err := git.PlainInit(upstreamDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, upstream, "lib.go", "package lib\n")
+	head, err := upstream.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	branch := head.Name().Short()
+	code := t.TempDir()
+	m := &Manifest{Repos: []Repo{
+		{URL: "file://" + upstreamDir, Branch: branch, Dir: "lib"},
+		{URL: "file://" + filepath.Join(t.TempDir(), "missing"), Dir: "missing"},
+	}}
+	sync := func(want Status) Result {
+		t.Helper()
+		res := Sync(ctx, code, m, 2)
+		if len(res) != 2 {
+			t.Fatalf("got %d results, want 2", len(res))
+		}
+		if res[1].Status != StatusFailed {
+			t.Errorf("missing remote: status %s, want Failed", res[1].Status)
+		}
+		if res[0].Status != want {
+			t.Fatalf("status %s (%s), want %s", res[0].Status, res[0].Note, want)
+		}
+		return res[0]
+	}
+
+	res := sync(StatusCloned)
+	if res.After != head.Hash().String() {
+		t.Errorf("cloned %s, want %s", res.After, head.Hash())
+	}
+	if _, err := os.Stat(filepath.Join(code, "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("failed clone left a directory behind: %v", err)
+	}
+	sync(StatusOK)
+
+	// new commits and tags upstream are fast-forwarded and fetched:
+	commitFile(t, upstream, "lib.go", "package lib\n\nfunc Add(a, b int) int { return a + b }\n")
+	head, _ = upstream.Head()
+	if _, err := upstream.CreateTag("v1.0.0", head.Hash(), nil); err != nil {
+		t.Fatal(err)
+	}
+	res = sync(StatusUpdated)
+	if res.After != head.Hash().String() || res.Before == res.After {
+		t.Errorf("updated %s..%s, want ..%s", res.Before, res.After, head.Hash())
+	}
+	local, err := git.PlainOpen(filepath.Join(code, "lib"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := local.Tag("v1.0.0"); err != nil {
+		t.Errorf("tag not fetched: %v", err)
+	}
+	src, err := os.ReadFile(filepath.Join(code, "lib", "lib.go"))
+	if err != nil || len(src) == len("package lib\n") {
+		t.Errorf("worktree not updated: %q, %v", src, err)
+	}
+
+	// local changes block the fast-forward:
+	commitFile(t, upstream, "lib.go", "package lib\n\n// Add adds.\nfunc Add(a, b int) int { return a + b }\n")
+	if err := os.WriteFile(filepath.Join(code, "lib", "lib.go"), []byte("package lib // edited\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sync(StatusDirty)
+	if err := os.WriteFile(filepath.Join(code, "lib", "lib.go"), src, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sync(StatusUpdated)
+
+	// a local commit puts the checkout ahead, and an upstream commit on top makes them diverge:
+	commitFile(t, local, "local.go", "package lib\n")
+	res = sync(StatusOK)
+	if res.Note == "" {
+		t.Errorf("ahead of upstream without a note")
+	}
+	commitFile(t, upstream, "upstream.go", "package lib\n")
+	sync(StatusDiverged)
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		err  error
+		want Status
+	}{
+		{err: transport.ErrAuthenticationRequired, want: StatusAuthFailure},
+		{err: transport.ErrAuthorizationFailed, want: StatusAuthFailure},
+		{err: errors.New("ssh: handshake failed: ssh: unable to authenticate, attempted methods [none publickey]"), want: StatusAuthFailure},
+		{err: transport.ErrRepositoryNotFound, want: StatusFailed},
+		{err: context.Canceled, want: StatusFailed},
+	}
+	for _, tt := range tests {
+		if got := classify(tt.err); got != tt.want {
+			t.Errorf("classify(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package reposync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRunning is returned by Syncer.Run when a sync is already in progress.
+var ErrRunning = errors.New("a sync is already running")
+
+// Syncer runs syncs of a manifest on demand and remembers the outcome of the last one.
+type Syncer struct {
+	Manifest string // path of the manifest, read again on every run
+	Dir      string // directory holding the checkouts
+	Parallel int    // maximum number of repositories synced at a time
+
+	mu      sync.Mutex
+	running bool
+	last    []Result
+	lastRun time.Time
+}
+
+// NewSyncer returns a Syncer syncing the manifest into dir.
+func NewSyncer(manifest, dir string, parallel int) *Syncer {
+	return &Syncer{Manifest: manifest, Dir: dir, Parallel: parallel}
+}
+
+// Run syncs the repositories of the manifest. Only one sync runs at a time.
+func (s *Syncer) Run(ctx context.Context) ([]Result, error) {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return nil, ErrRunning
+	}
+	s.running = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+	}()
+
+	m, err := LoadManifest(s.Manifest)
+	if err != nil {
+		return nil, err
+	}
+	results := Sync(ctx, s.Dir, m, s.Parallel)
+	s.mu.Lock()
+	s.last, s.lastRun = results, time.Now()
+	s.mu.Unlock()
+	return results, nil
+}
+
+// Last returns the results of the last sync and when it ran. The time is zero if there
+// hasn't been a sync.
+func (s *Syncer) Last() ([]Result, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last, s.lastRun
+}
+
+// Running returns true while a sync is in progress.
+func (s *Syncer) Running() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running
+}
@@ -0,0 +1,101 @@
+package sbom
+
+import "time"
+
+// The CycloneDX 1.5 JSON format, as much of it as gogrok fills in.
+
+type cdxBOM struct {
+	BOMFormat    string          `json:"bomFormat"`
+	SpecVersion  string          `json:"specVersion"`
+	SerialNumber string          `json:"serialNumber"`
+	Version      int             `json:"version"`
+	Metadata     cdxMetadata     `json:"metadata"`
+	Components   []cdxComponent  `json:"components"`
+	Dependencies []cdxDependency `json:"dependencies"`
+}
+
+type cdxMetadata struct {
+	Timestamp string       `json:"timestamp"`
+	Tools     cdxTools     `json:"tools"`
+	Component cdxComponent `json:"component"`
+}
+
+type cdxTools struct {
+	Components []cdxComponent `json:"components"`
+}
+
+type cdxComponent struct {
+	BOMRef   string       `json:"bom-ref,omitempty"`
+	Type     string       `json:"type"`
+	Name     string       `json:"name"`
+	Version  string       `json:"version,omitempty"`
+	PURL     string       `json:"purl,omitempty"`
+	Hashes   []cdxHash    `json:"hashes,omitempty"`
+	Licenses []cdxLicense `json:"licenses,omitempty"`
+}
+
+// cdxLicense is a license choice, given as an SPDX expression.
+type cdxLicense struct {
+	Expression string `json:"expression"`
+}
+
+type cdxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cdxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+func (d *Document) cycloneDX() cdxBOM {
+	root := cdxComponent{
+		BOMRef:  purl(d.Module, d.Version),
+		Type:    "application",
+		Name:    d.Module,
+		Version: d.Version,
+		PURL:    purl(d.Module, d.Version),
+	}
+	if d.Library {
+		root.Type = "library"
+	}
+	root.Licenses = cdxLicenses(d.License)
+	bom := cdxBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + d.ID,
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: d.Created.Format(time.RFC3339),
+			Tools:     cdxTools{Components: []cdxComponent{{Type: "application", Name: "gogrok"}}},
+			Component: root,
+		},
+		Components: make([]cdxComponent, 0, len(d.Requires)),
+	}
+	dependsOn := make([]string, 0, len(d.Requires))
+	for _, req := range d.Requires {
+		c := cdxComponent{
+			BOMRef:  purl(req.Path, req.Version),
+			Type:    "library",
+			Name:    req.Path,
+			Version: req.Version,
+			PURL:    purl(req.Path, req.Version),
+		}
+		if h := sha256Hex(req.Sum); h != "" {
+			c.Hashes = []cdxHash{{Alg: "SHA-256", Content: h}}
+		}
+		c.Licenses = cdxLicenses(req.License)
+		bom.Components = append(bom.Components, c)
+		dependsOn = append(dependsOn, c.BOMRef)
+	}
+	bom.Dependencies = []cdxDependency{{Ref: root.BOMRef, DependsOn: dependsOn}}
+	return bom
+}
+
+func cdxLicenses(expression string) []cdxLicense {
+	if expression == "" {
+		return nil
+	}
+	return []cdxLicense{{Expression: expression}}
+}
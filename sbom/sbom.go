@@ -0,0 +1,141 @@
+// Package sbom writes software bills of materials for local modules: the modules they require,
+// with versions, go.sum hashes and licenses, as CycloneDX 1.5 JSON or SPDX 2.3 JSON.
+package sbom
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/perbu/gogrok/analytics"
+	"io"
+	"strings"
+	"time"
+)
+
+// Format is an SBOM file format.
+type Format string
+
+const (
+	CycloneDX Format = "cyclonedx"
+	SPDX      Format = "spdx"
+)
+
+// Formats are the supported formats.
+var Formats = []Format{CycloneDX, SPDX}
+
+// ParseFormat parses the name of a format.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown format %q, want cyclonedx or spdx", s)
+}
+
+// Extension returns the conventional file name extension of the format.
+func (f Format) Extension() string {
+	if f == SPDX {
+		return "spdx.json"
+	}
+	return "cdx.json"
+}
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	if f == SPDX {
+		return "application/spdx+json"
+	}
+	return "application/vnd.cyclonedx+json"
+}
+
+// Document is what goes into an SBOM of a module, whatever the format.
+type Document struct {
+	Module   string
+	Version  string // the ref of a snapshot, otherwise the latest tag
+	Library  bool   // the module has importable packages
+	License  string // SPDX license expression, empty if unknown
+	Requires []analytics.Requirement
+	Created  time.Time
+	ID       string // random UUID identifying the document
+}
+
+// New returns the SBOM of a local module.
+func New(m *analytics.Module) (*Document, error) {
+	id, err := uuid()
+	if err != nil {
+		return nil, fmt.Errorf("sbom.New: %w", err)
+	}
+	version := m.Ref
+	if version == "" {
+		version = m.Latest()
+	}
+	return &Document{
+		Module:   m.Path,
+		Version:  version,
+		Library:  m.Library(),
+		License:  m.License().Expression(),
+		Requires: m.Requirements(),
+		Created:  time.Now().UTC(),
+		ID:       id,
+	}, nil
+}
+
+// Filename returns a name to save the SBOM of a module as, the module path flattened.
+func Filename(module string, f Format) string {
+	return strings.ReplaceAll(module, "/", "_") + "." + f.Extension()
+}
+
+// Write writes the document in the format.
+func (d *Document) Write(w io.Writer, f Format) error {
+	var doc any
+	switch f {
+	case CycloneDX:
+		doc = d.cycloneDX()
+	case SPDX:
+		doc = d.spdx()
+	default:
+		return fmt.Errorf("sbom.Write: unknown format %q", f)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("json Encode: %w", err)
+	}
+	return nil
+}
+
+// purl returns the package URL of a module version.
+func purl(path, version string) string {
+	if version == "" {
+		return "pkg:golang/" + path
+	}
+	return "pkg:golang/" + path + "@" + version
+}
+
+// sha256Hex turns a go.sum h1: hash, which is a base64 encoded SHA-256, into hex. It returns
+// the empty string for other hashes.
+func sha256Hex(sum string) string {
+	b64, ok := strings.CutPrefix(sum, "h1:")
+	if !ok {
+		return ""
+	}
+	b, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil || len(b) != 32 {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// uuid returns a random (version 4) UUID.
+func uuid() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
@@ -0,0 +1,94 @@
+package sbom
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/perbu/gogrok/analytics"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// emptySum is the h1: hash of SHA-256 of nothing, e3b0c442...
+const emptySum = "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+
+var doc = &Document{
+	Module:  "example.com/svc",
+	Version: "v1.2.0",
+	License: "MIT",
+	Requires: []analytics.Requirement{
+		{Path: "example.com/lib", Version: "v1.0.0", Sum: emptySum, License: "Apache-2.0 OR MIT"},
+		{Path: "golang.org/x/mod", Version: "v0.17.0"},
+	},
+	Created: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	ID:      "2c8e8a4e-7c55-4c4b-9a3e-000000000001",
+}
+
+func TestCycloneDX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := doc.Write(&buf, CycloneDX); err != nil {
+		t.Fatal(err)
+	}
+	var bom cdxBOM
+	if err := json.Unmarshal(buf.Bytes(), &bom); err != nil {
+		t.Fatal(err)
+	}
+	if bom.BOMFormat != "CycloneDX" || bom.SerialNumber != "urn:uuid:"+doc.ID || bom.Metadata.Timestamp != "2024-05-01T12:00:00Z" {
+		t.Errorf("header: %+v", bom)
+	}
+	if root := bom.Metadata.Component; root.Type != "application" || root.PURL != "pkg:golang/example.com/svc@v1.2.0" {
+		t.Errorf("root component: %+v", root)
+	}
+	if len(bom.Components) != 2 {
+		t.Fatalf("components: %+v", bom.Components)
+	}
+	lib := bom.Components[0]
+	want := cdxHash{Alg: "SHA-256", Content: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"}
+	if lib.PURL != "pkg:golang/example.com/lib@v1.0.0" || len(lib.Hashes) != 1 || lib.Hashes[0] != want {
+		t.Errorf("component: %+v", lib)
+	}
+	if len(lib.Licenses) != 1 || lib.Licenses[0].Expression != "Apache-2.0 OR MIT" || bom.Metadata.Component.Licenses[0].Expression != "MIT" {
+		t.Errorf("licenses: %+v", lib.Licenses)
+	}
+	if bom.Components[1].Hashes != nil || bom.Components[1].Licenses != nil {
+		t.Errorf("component without go.sum entry or license has them: %+v", bom.Components[1])
+	}
+	if deps := bom.Dependencies; len(deps) != 1 || deps[0].Ref != bom.Metadata.Component.BOMRef || len(deps[0].DependsOn) != 2 {
+		t.Errorf("dependencies: %+v", deps)
+	}
+}
+
+func TestSPDX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := doc.Write(&buf, SPDX); err != nil {
+		t.Fatal(err)
+	}
+	var d spdxDocument
+	if err := json.Unmarshal(buf.Bytes(), &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.SPDXVersion != "SPDX-2.3" || d.CreationInfo.Created != "2024-05-01T12:00:00Z" || len(d.Packages) != 3 {
+		t.Fatalf("document: %+v", d)
+	}
+	if lib := d.Packages[1]; lib.Name != "example.com/lib" || lib.Checksums[0].Algorithm != "SHA256" || lib.ExternalRefs[0].ReferenceLocator != "pkg:golang/example.com/lib@v1.0.0" {
+		t.Errorf("package: %+v", lib)
+	}
+	if d.Packages[0].LicenseDeclared != "MIT" || d.Packages[1].LicenseDeclared != "Apache-2.0 OR MIT" || d.Packages[2].LicenseDeclared != noAssertion {
+		t.Errorf("declared licenses: %s, %s, %s", d.Packages[0].LicenseDeclared, d.Packages[1].LicenseDeclared, d.Packages[2].LicenseDeclared)
+	}
+	if len(d.Relationships) != 3 || d.Relationships[0].RelationshipType != "DESCRIBES" || d.Relationships[2] != (spdxRelationship{
+		SPDXElementID: "SPDXRef-Package-0", RelationshipType: "DEPENDS_ON", RelatedSPDXElement: "SPDXRef-Package-2",
+	}) {
+		t.Errorf("relationships: %+v", d.Relationships)
+	}
+}
+
+func TestUUID(t *testing.T) {
+	id, err := uuid()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(id) {
+		t.Errorf("not a version 4 UUID: %s", id)
+	}
+}
@@ -0,0 +1,107 @@
+package sbom
+
+import (
+	"strconv"
+	"time"
+)
+
+// The SPDX 2.3 JSON format, as much of it as gogrok fills in.
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	CopyrightText    string            `json:"copyrightText"`
+	Checksums        []spdxChecksum    `json:"checksums,omitempty"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// noAssertion is SPDX for "not known".
+const noAssertion = "NOASSERTION"
+
+func (d *Document) spdx() spdxDocument {
+	pkg := func(id int, path, version, sum, license string) spdxPackage {
+		p := spdxPackage{
+			Name:             path,
+			SPDXID:           "SPDXRef-Package-" + strconv.Itoa(id),
+			VersionInfo:      version,
+			DownloadLocation: noAssertion,
+			LicenseConcluded: noAssertion,
+			LicenseDeclared:  noAssertion,
+			CopyrightText:    noAssertion,
+			ExternalRefs: []spdxExternalRef{{
+				ReferenceCategory: "PACKAGE-MANAGER",
+				ReferenceType:     "purl",
+				ReferenceLocator:  purl(path, version),
+			}},
+		}
+		if license != "" {
+			p.LicenseDeclared = license
+		}
+		if h := sha256Hex(sum); h != "" {
+			p.Checksums = []spdxChecksum{{Algorithm: "SHA256", ChecksumValue: h}}
+		}
+		return p
+	}
+	doc := spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              d.Module,
+		DocumentNamespace: "https://spdx.org/spdxdocs/gogrok/" + d.Module + "-" + d.ID,
+		CreationInfo: spdxCreationInfo{
+			Created:  d.Created.Format(time.RFC3339),
+			Creators: []string{"Tool: gogrok"},
+		},
+		Packages: []spdxPackage{pkg(0, d.Module, d.Version, "", d.License)},
+	}
+	root := doc.Packages[0].SPDXID
+	doc.Relationships = []spdxRelationship{{SPDXElementID: doc.SPDXID, RelationshipType: "DESCRIBES", RelatedSPDXElement: root}}
+	for i, req := range d.Requires {
+		p := pkg(i+1, req.Path, req.Version, req.Sum, req.License)
+		doc.Packages = append(doc.Packages, p)
+		doc.Relationships = append(doc.Relationships, spdxRelationship{
+			SPDXElementID:      root,
+			RelationshipType:   "DEPENDS_ON",
+			RelatedSPDXElement: p.SPDXID,
+		})
+	}
+	return doc
+}
@@ -0,0 +1,166 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"io/fs"
+	"log/slog"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Watcher watches a directory of checkouts, like code/, and reports which checkouts changed.
+// Changes to Go source, go.mod and the git HEAD, branches and tags count, everything else
+// is ignored.
+type Watcher struct {
+	dir      string
+	debounce time.Duration
+	fsw      *fsnotify.Watcher
+}
+
+// New starts watching dir. Changes are reported once nothing has changed for the debounce
+// period, so a git pull or checkout is reported once.
+func New(dir string, debounce time.Duration) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("fsnotify.NewWatcher: %w", err)
+	}
+	w := &Watcher{dir: dir, debounce: debounce, fsw: fsw}
+	if err := w.addTree(dir); err != nil {
+		_ = fsw.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Run delivers the names of the changed checkouts, relative to the watched directory, to
+// onChange until the context is cancelled. onChange is never called concurrently, changes
+// made while it runs are delivered on the next call.
+func (w *Watcher) Run(ctx context.Context, onChange func(changed []string)) error {
+	defer w.fsw.Close()
+	pending := make(map[string]struct{})
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Warn("file watcher", "error", err)
+		case ev, ok := <-w.fsw.Events:
+			if !ok {
+				return nil
+			}
+			w.follow(ev)
+			checkout, ok := w.relevant(ev)
+			if !ok {
+				continue
+			}
+			pending[checkout] = struct{}{}
+			timer.Reset(w.debounce)
+		case <-timer.C:
+			changed := make([]string, 0, len(pending))
+			for checkout := range pending {
+				changed = append(changed, checkout)
+			}
+			sort.Strings(changed)
+			clear(pending)
+			slog.Info("checkouts changed", "checkouts", changed)
+			onChange(changed)
+		}
+	}
+}
+
+// follow starts watching directories as they are created.
+func (w *Watcher) follow(ev fsnotify.Event) {
+	if !ev.Has(fsnotify.Create) {
+		return
+	}
+	if err := w.addTree(ev.Name); err != nil {
+		slog.Debug("file watcher", "path", ev.Name, "error", err)
+	}
+}
+
+// addTree watches root and the directories beneath it. Inside .git only the directories
+// holding HEAD and refs are watched.
+func (w *Watcher) addTree(root string) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(w.dir, p)
+		if err != nil {
+			return err
+		}
+		if !watched(filepath.ToSlash(rel)) {
+			return filepath.SkipDir
+		}
+		if err := w.fsw.Add(p); err != nil {
+			return fmt.Errorf("watch %s: %w", p, err)
+		}
+		return nil
+	})
+}
+
+// watched tells if a directory, relative to the watched directory, should be watched.
+func watched(rel string) bool {
+	_, inside, _ := strings.Cut(rel, "/")
+	gitDir, ok := strings.CutPrefix(inside, ".git")
+	if !ok || (gitDir != "" && gitDir[0] != '/') {
+		return true
+	}
+	gitDir = strings.TrimPrefix(gitDir, "/")
+	return gitDir == "" || gitDir == "refs" ||
+		strings.HasPrefix(gitDir, "refs/heads") || strings.HasPrefix(gitDir, "refs/tags")
+}
+
+// relevant returns the checkout an event belongs to, if the event could change the analysis.
+func (w *Watcher) relevant(ev fsnotify.Event) (string, bool) {
+	if ev.Has(fsnotify.Chmod) && !ev.Has(fsnotify.Write) {
+		return "", false
+	}
+	rel, err := filepath.Rel(w.dir, ev.Name)
+	if err != nil {
+		return "", false
+	}
+	return relevantPath(filepath.ToSlash(rel))
+}
+
+// relevantPath returns the checkout a changed path, relative to the watched directory,
+// belongs to if the change could affect the analysis.
+func relevantPath(rel string) (string, bool) {
+	checkout, inside, found := strings.Cut(rel, "/")
+	if checkout == "." || checkout == ".." || strings.HasPrefix(checkout, ".") {
+		return "", false
+	}
+	if !found {
+		// a checkout was added or removed
+		return checkout, true
+	}
+	if gitPath, ok := strings.CutPrefix(inside, ".git/"); ok {
+		switch {
+		case gitPath == "HEAD", gitPath == "packed-refs":
+			return checkout, true
+		case strings.HasSuffix(gitPath, ".lock"):
+			return "", false
+		case strings.HasPrefix(gitPath, "refs/heads/"), strings.HasPrefix(gitPath, "refs/tags/"):
+			return checkout, true
+		}
+		return "", false
+	}
+	base := path.Base(inside)
+	if base == "go.mod" || (path.Ext(base) == ".go" && !strings.HasPrefix(base, ".")) {
+		return checkout, true
+	}
+	return "", false
+}
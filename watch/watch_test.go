@@ -0,0 +1,108 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRelevantPath(t *testing.T) {
+	tests := []struct {
+		rel          string
+		wantCheckout string
+		wantOk       bool
+	}{
+		{rel: "svc", wantCheckout: "svc", wantOk: true},
+		{rel: "svc/main.go", wantCheckout: "svc", wantOk: true},
+		{rel: "svc/internal/db/db.go", wantCheckout: "svc", wantOk: true},
+		{rel: "svc/go.mod", wantCheckout: "svc", wantOk: true},
+		{rel: "svc/.git/HEAD", wantCheckout: "svc", wantOk: true},
+		{rel: "svc/.git/packed-refs", wantCheckout: "svc", wantOk: true},
+		{rel: "svc/.git/refs/tags/v1.0.0", wantCheckout: "svc", wantOk: true},
+		{rel: "svc/.git/refs/heads/main", wantCheckout: "svc", wantOk: true},
+		{rel: "svc/.git/refs/heads/main.lock", wantOk: false},
+		{rel: "svc/.git/objects/ab/cdef", wantOk: false},
+		{rel: "svc/.git/index", wantOk: false},
+		{rel: "svc/README.md", wantOk: false},
+		{rel: "svc/go.sum", wantOk: false},
+		{rel: "svc/.main.go.swp", wantOk: false},
+		{rel: ".DS_Store", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rel, func(t *testing.T) {
+			checkout, ok := relevantPath(tt.rel)
+			if checkout != tt.wantCheckout || ok != tt.wantOk {
+				t.Errorf("relevantPath(%q) = %q, %v; want %q, %v", tt.rel, checkout, ok, tt.wantCheckout, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestWatched(t *testing.T) {
+	tests := map[string]bool{
+		".":                  true,
+		"svc":                true,
+		"svc/internal":       true,
+		"svc/.github":        true,
+		"svc/.git":           true,
+		"svc/.git/refs":      true,
+		"svc/.git/refs/tags": true,
+		"svc/.git/objects":   false,
+		"svc/.git/logs":      false,
+		"svc/.gitlab":        true,
+	}
+	for rel, want := range tests {
+		if got := watched(rel); got != want {
+			t.Errorf("watched(%q) = %v, want %v", rel, got, want)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "svc", "pkg"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	w, err := New(dir, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan []string, 1)
+	go func() {
+		_ = w.Run(ctx, func(changed []string) { changes <- changed })
+	}()
+	write := func(name string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("package pkg\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("svc/README.md")
+	write("svc/pkg/pkg.go")
+	select {
+	case changed := <-changes:
+		if len(changed) != 1 || changed[0] != "svc" {
+			t.Errorf("changed = %v, want [svc]", changed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change reported")
+	}
+	// directories created after the start are watched too:
+	if err := os.MkdirAll(filepath.Join(dir, "svc", "newpkg"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// give the watcher time to pick up the new directory:
+	time.Sleep(200 * time.Millisecond)
+	write("svc/newpkg/new.go")
+	select {
+	case changed := <-changes:
+		if len(changed) != 1 || changed[0] != "svc" {
+			t.Errorf("changed = %v, want [svc]", changed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change reported for a file in a new directory")
+	}
+}